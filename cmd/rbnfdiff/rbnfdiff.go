@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/stts-se/rbnf"
+	"github.com/stts-se/rbnf/xmlreader"
+)
+
+func main() {
+
+	cmd := path.Base(os.Args[0])
+
+	// Flags
+	var flags = flag.NewFlagSet(cmd, flag.ExitOnError)
+	inherit := flags.Bool("i", false, "Inherit rule sets from the parent locale files in the same directory (de_CH.xml: de.xml, root.xml)")
+	jsonOutput := flags.Bool("j", false, "Print changes as json")
+	help := flags.Bool("h", false, "Print usage and exit")
+	flags.Parse(os.Args[1:])
+	args := flags.Args()
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s <options> <old xml file/url> <new xml file/url>\n", cmd)
+		fmt.Fprintf(os.Stderr, "  prints the changes to rule set groups, rule sets and rules, one per line\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flags.PrintDefaults()
+	}
+
+	if *help || len(args) != 2 {
+		flags.Usage()
+		os.Exit(0)
+	}
+
+	read := func(f string) rbnf.RulePackage {
+		var res rbnf.RulePackage
+		var err error
+		if strings.HasPrefix(f, "http") {
+			res, err = xmlreader.RulesFromXMLURL(f)
+		} else if *inherit {
+			res, err = xmlreader.RulesFromXMLFileInherited(f)
+		} else {
+			res, err = xmlreader.RulesFromXMLFile(f)
+		}
+		if err != nil {
+			log.Fatalf("Couldn't parse file %s : %v", f, err)
+		}
+		return res
+	}
+	changes := rbnf.Diff(read(args[0]), read(args[1]))
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(changes); err != nil {
+			log.Fatalf("Couldn't marshal changes : %v", err)
+		}
+		return
+	}
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/stts-se/rbnf"
+	"github.com/stts-se/rbnf/xmlreader"
+)
+
+func writeFile(fn string, write func(f *os.File) error) {
+	f, err := os.Create(fn)
+	if err != nil {
+		log.Fatalf("Couldn't create file %s : %v", fn, err)
+	}
+	if err := write(f); err != nil {
+		log.Fatalf("Couldn't write file %s : %v", fn, err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatalf("Couldn't close file %s : %v", fn, err)
+	}
+	log.Printf("Wrote %s", fn)
+}
+
+func main() {
+
+	cmd := path.Base(os.Args[0])
+
+	// Flags
+	var flags = flag.NewFlagSet(cmd, flag.ExitOnError)
+	ruleGroup := flags.String("g", "", "Use named `rule group` (default the group with the rule set)")
+	lower := flags.Int("l", 0, "Lower limit of the integer range")
+	upper := flags.Int("u", 999999, "Upper limit of the integer range")
+	output := flags.String("o", "", "Output file `prefix` (default rule set name)")
+	samples := flags.Int("c", 1000, "Check the FST against spellout for `n` sampled numbers (0 to skip)")
+	seed := flags.Int64("s", 1, "Random `seed` for sampling")
+	cost := flags.Float64("w", 0, "Weight added for each rule applied (rule `cost`)")
+	help := flags.Bool("h", false, "Print usage and exit")
+	flags.Parse(os.Args[1:])
+	args := flags.Args()
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s <options> <xml file/url> <rule set>\n", cmd)
+		fmt.Fprintf(os.Stderr, "  compiles a public rule set into an FST in AT&T text format, with symbol tables:\n")
+		fmt.Fprintf(os.Stderr, "  <prefix>.att, <prefix>.isyms and <prefix>.osyms\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flags.PrintDefaults()
+	}
+
+	if *help || len(args) != 2 {
+		flags.Usage()
+		os.Exit(0)
+	}
+
+	f, ruleSet := args[0], args[1]
+	var rPackage rbnf.RulePackage
+	var err error
+	if strings.HasPrefix(f, "http") {
+		rPackage, err = xmlreader.RulesFromXMLURL(f)
+	} else {
+		rPackage, err = xmlreader.RulesFromXMLFile(f)
+	}
+	if err != nil {
+		log.Fatalf("Couldn't parse file %s : %v", f, err)
+	}
+
+	var group *rbnf.RuleSetGroup
+	for i, g := range rPackage.RuleSetGroups {
+		if _, ok := g.FindRuleSet(ruleSet); ok && (*ruleGroup == "" || g.Name == *ruleGroup) {
+			group = &rPackage.RuleSetGroups[i]
+			break
+		}
+	}
+	if group == nil {
+		log.Fatalf("Couldn't find rule set %s/%s in rule file %s", *ruleGroup, ruleSet, f)
+	}
+	if rs, ok := group.FindRuleSet(ruleSet); ok && rs.Private {
+		log.Fatalf("Rule set %s/%s is private", group.Name, ruleSet)
+	}
+
+	fst, err := group.FST(ruleSet, *lower, *upper, rbnf.FSTRuleCost(*cost))
+	if err != nil {
+		log.Fatalf("Couldn't compile FST : %v", err)
+	}
+	log.Printf("Compiled %s/%s for %d..%d: %d states, %d arcs", group.Name, ruleSet, *lower, *upper, fst.NumStates, len(fst.Arcs))
+	if *samples > 0 {
+		if err := fst.Check(*samples, rand.New(rand.NewSource(*seed))); err != nil {
+			log.Fatalf("FST check failed : %v", err)
+		}
+		log.Printf("Checked %d sampled numbers against spellout", *samples)
+	}
+
+	prefix := *output
+	if prefix == "" {
+		prefix = ruleSet
+	}
+	writeFile(prefix+".att", func(f *os.File) error { return fst.WriteATT(f) })
+	writeFile(prefix+".isyms", func(f *os.File) error { return rbnf.WriteSymbols(f, fst.InputSymbols()) })
+	writeFile(prefix+".osyms", func(f *os.File) error { return rbnf.WriteSymbols(f, fst.OutputSymbols()) })
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/stts-se/rbnf"
+	"github.com/stts-se/rbnf/xmlreader"
+)
+
+// input is a rule package read from a rule file
+type input struct {
+	file string
+	pack rbnf.RulePackage
+}
+
+// funcName returns the constructor name for a language: sv => Sv, de_CH => DeCH, en_001 => En001
+func funcName(lang rbnf.Language) string {
+	res := ""
+	for _, part := range strings.FieldsFunc(string(lang), func(r rune) bool { return r == '_' || r == '-' }) {
+		rs := []rune(part)
+		rs[0] = unicode.ToUpper(rs[0])
+		res += string(rs)
+	}
+	return res
+}
+
+// varName returns the prefix of the unexported names for a language: sv => sv, de_CH => deCH
+func varName(lang rbnf.Language) string {
+	rs := []rune(funcName(lang))
+	rs[0] = unicode.ToLower(rs[0])
+	return string(rs)
+}
+
+func writeSub(w io.Writer, sub rbnf.Sub) {
+	fields := []string{}
+	if sub.Optional {
+		fields = append(fields, "Optional: true")
+	}
+	if sub.Orth != "" {
+		fields = append(fields, "Orth: "+strconv.Quote(sub.Orth))
+	}
+	if sub.RuleRef != "" {
+		fields = append(fields, "RuleRef: "+strconv.Quote(sub.RuleRef))
+	}
+	if sub.IsNumericFormatter() {
+		f := sub.NumericFormatter
+		fields = append(fields, fmt.Sprintf("NumericFormatter: rbnf.NewNumericFormatter(%q, %q)", f.Language(), f.String()))
+	}
+	if sub.IsPluralFormatter() {
+		f := sub.PluralFormatter
+		fields = append(fields, fmt.Sprintf("PluralFormatter: fs.plural(%q, %q)", f.Language(), f.String()))
+	}
+	if sub.Operation != "" {
+		fields = append(fields, "Operation: "+strconv.Quote(sub.Operation))
+	}
+	fmt.Fprintf(w, "{%s},", strings.Join(fields, ", "))
+}
+
+func writeRule(w io.Writer, r rbnf.BaseRule) {
+	if r.Base.IsInt() && r.Base.Decrement > 0 {
+		fmt.Fprintf(w, "{Base: rbnf.Base{Int: %d, Radix: %d, Decrement: %d}, Subs: []rbnf.Sub{", r.Base.Int, r.Base.Radix, r.Base.Decrement)
+	} else if r.Base.IsInt() {
+		fmt.Fprintf(w, "{Base: rbnf.Base{Int: %d, Radix: %d}, Subs: []rbnf.Sub{", r.Base.Int, r.Base.Radix)
+	} else {
+		fmt.Fprintf(w, "{Base: rbnf.Base{String: %q}, Subs: []rbnf.Sub{", r.Base.String)
+	}
+	for _, sub := range r.Subs {
+		writeSub(w, sub)
+	}
+	fmt.Fprintf(w, "}},\n")
+}
+
+func writePackage(w io.Writer, in input) {
+	pack := in.pack
+	name := funcName(pack.Language)
+	v := varName(pack.Language)
+	fmt.Fprintf(w, "var (\n%sOnce sync.Once\n%sPack rbnf.RulePackage\n%sErr error\n)\n\n", v, v, v)
+	fmt.Fprintf(w, "// %s returns the rule package for %s, generated from %s. The package is built on the first call,\n", name, pack.Language, path.Base(in.file))
+	fmt.Fprintf(w, "// and shared by all callers.\n")
+	fmt.Fprintf(w, "func %s() (rbnf.RulePackage, error) {\n", name)
+	fmt.Fprintf(w, "%sOnce.Do(func() { %sPack, %sErr = build%s() })\n", v, v, v, name)
+	fmt.Fprintf(w, "return %sPack, %sErr\n}\n\n", v, v)
+	fmt.Fprintf(w, "func build%s() (rbnf.RulePackage, error) {\n", name)
+	fmt.Fprintf(w, "fs := &formatters{}\n")
+	fmt.Fprintf(w, "pack := rbnf.RulePackage{\n")
+	fmt.Fprintf(w, "Language: %q,\n", pack.Language)
+	fmt.Fprintf(w, "RuleSetGroups: []rbnf.RuleSetGroup{\n")
+	for _, g := range pack.RuleSetGroups {
+		fmt.Fprintf(w, "{\nName: %q,\nLanguage: %q,\nRuleSets: map[string]rbnf.RuleSet{\n", g.Name, g.Language)
+		names := []string{}
+		for name := range g.RuleSets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			rs := g.RuleSets[name]
+			fmt.Fprintf(w, "%q: {\nName: %q,\n", name, rs.Name)
+			if rs.Private {
+				fmt.Fprintf(w, "Private: true,\n")
+			}
+			fmt.Fprintf(w, "Rules: []rbnf.BaseRule{\n")
+			for _, r := range rs.Rules {
+				writeRule(w, r)
+			}
+			fmt.Fprintf(w, "},\n},\n")
+		}
+		fmt.Fprintf(w, "},\n},\n")
+	}
+	fmt.Fprintf(w, "},\n}\n")
+	fmt.Fprintf(w, "return pack, fs.err\n}\n\n")
+}
+
+// generate writes a Go source file with a constructor for each rule package, a map of the
+// constructors by language, and a function registering them in a rbnf.Registry
+func generate(w io.Writer, pkgName string, inputs []input) error {
+	seen := make(map[string]string)
+	for _, in := range inputs {
+		name := funcName(in.pack.Language)
+		if f, ok := seen[name]; ok {
+			return fmt.Errorf("duplicate language %s in %s and %s", in.pack.Language, f, in.file)
+		}
+		seen[name] = in.file
+	}
+
+	var buf bytes.Buffer
+	files := []string{}
+	for _, in := range inputs {
+		files = append(files, path.Base(in.file))
+	}
+	fmt.Fprintf(&buf, "// Code generated by rbnfgen from %s; DO NOT EDIT.\n\n", strings.Join(files, ", "))
+	fmt.Fprintf(&buf, "package %s\n\n", pkgName)
+	fmt.Fprintf(&buf, "import (\n\"fmt\"\n\"sync\"\n\n\"github.com/stts-se/rbnf\"\n)\n\n")
+
+	fmt.Fprintf(&buf, "// Packages holds the rule package constructors, by language\n")
+	fmt.Fprintf(&buf, "var Packages = map[rbnf.Language]func() (rbnf.RulePackage, error){\n")
+	for _, in := range inputs {
+		fmt.Fprintf(&buf, "%q: %s,\n", in.pack.Language, funcName(in.pack.Language))
+	}
+	fmt.Fprintf(&buf, "}\n\n")
+
+	fmt.Fprintf(&buf, "// Register registers the rule packages in a registry\n")
+	fmt.Fprintf(&buf, "func Register(reg *rbnf.Registry) {\n")
+	fmt.Fprintf(&buf, "for lang, f := range Packages {\nreg.Register(lang.Tag(), f)\n}\n}\n\n")
+
+	fmt.Fprintf(&buf, "// formatters creates the plural formatters of a rule package, keeping the first error\n")
+	fmt.Fprintf(&buf, "type formatters struct {\nerr error\n}\n\n")
+	fmt.Fprintf(&buf, "func (fs *formatters) plural(lang rbnf.Language, format string) rbnf.PluralFormatter {\n")
+	fmt.Fprintf(&buf, "f, err := rbnf.NewPluralFormatter(lang, format)\nif err != nil && fs.err == nil {\n")
+	fmt.Fprintf(&buf, "fs.err = fmt.Errorf(\"invalid plural formatter %%s : %%v\", format, err)\n}\nreturn f\n}\n\n")
+
+	for _, in := range inputs {
+		writePackage(&buf, in)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("couldn't format generated source : %v", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+func main() {
+
+	cmd := path.Base(os.Args[0])
+
+	// Flags
+	var flags = flag.NewFlagSet(cmd, flag.ExitOnError)
+	pkgName := flags.String("p", "rules", "Go `package` name of the generated source")
+	outFile := flags.String("o", "", "Write the generated source to `file` (default stdout)")
+	inherit := flags.Bool("i", false, "Inherit rule sets from the parent locale files in the same directory (de_CH.xml: de.xml, root.xml)")
+	help := flags.Bool("h", false, "Print usage and exit")
+	flags.Parse(os.Args[1:])
+	args := flags.Args()
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s <options> <xml files>\n", cmd)
+		fmt.Fprintf(os.Stderr, "  generates Go source with a rbnf.RulePackage constructor for each rule file\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flags.PrintDefaults()
+	}
+
+	if *help || len(args) < 1 {
+		flags.Usage()
+		os.Exit(0)
+	}
+
+	inputs := []input{}
+	for _, f := range args {
+		var pack rbnf.RulePackage
+		var err error
+		if *inherit {
+			pack, err = xmlreader.RulesFromXMLFileInherited(f)
+		} else {
+			pack, err = xmlreader.RulesFromXMLFile(f)
+		}
+		if err != nil {
+			log.Fatalf("Couldn't parse file %s : %v", f, err)
+		}
+		inputs = append(inputs, input{file: f, pack: pack})
+	}
+
+	var buf bytes.Buffer
+	if err := generate(&buf, *pkgName, inputs); err != nil {
+		log.Fatalf("Couldn't generate source : %v", err)
+	}
+	if *outFile == "" {
+		os.Stdout.Write(buf.Bytes())
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(*outFile), 0755); err != nil {
+		log.Fatalf("Couldn't create output directory : %v", err)
+	}
+	if err := ioutil.WriteFile(*outFile, buf.Bytes(), 0644); err != nil {
+		log.Fatalf("Couldn't write output file %s : %v", *outFile, err)
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stts-se/rbnf"
+	"github.com/stts-se/rbnf/xmlreader"
+)
+
+var fs = "Expected '%v', got '%v'"
+
+func TestFuncName(t *testing.T) {
+	tests := map[rbnf.Language]string{
+		"sv":      "Sv",
+		"de_CH":   "DeCH",
+		"en_001":  "En001",
+		"zh_Hant": "ZhHant",
+	}
+	for lang, exp := range tests {
+		if res := funcName(lang); res != exp {
+			t.Errorf(fs, exp, res)
+		}
+	}
+}
+
+var testInputs = []string{"0", "1", "-7", "2.5", "13", "21", "99", "101", "1000", "1001", "1984", "2021", "12345", "1000000", "2000001", "123456789"}
+
+// spellouts returns the spellouts of the test inputs for all public rule sets of the package, one per line
+func spellouts(pack rbnf.RulePackage) string {
+	var lines []string
+	for _, g := range pack.RuleSetGroups {
+		for _, rs := range g.RuleSets {
+			if rs.Private {
+				continue
+			}
+			for _, input := range testInputs {
+				res, err := pack.Spellout(input, g.Name, rs.Name, false)
+				if err != nil {
+					res = "ERROR"
+				}
+				lines = append(lines, fmt.Sprintf("%s\t%s\t%s\t%s\t%s", pack.Language, g.Name, rs.Name, input, res))
+			}
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+const testGoMod = `module rbnfgentest
+
+go 1.16
+
+require (
+	github.com/stts-se/rbnf v0.0.0
+	golang.org/x/text v0.3.8
+)
+
+replace github.com/stts-se/rbnf => %s
+`
+
+const testMain = `package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stts-se/rbnf"
+	"rbnfgentest/rules"
+)
+
+var testInputs = %#v
+
+func main() {
+	langs := []string{}
+	for lang := range rules.Packages {
+		langs = append(langs, string(lang))
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		pack, err := rules.Packages[rbnf.Language(lang)]()
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		var lines []string
+		for _, g := range pack.RuleSetGroups {
+			for _, rs := range g.RuleSets {
+				if rs.Private {
+					continue
+				}
+				for _, input := range testInputs {
+					res, err := pack.Spellout(input, g.Name, rs.Name, false)
+					if err != nil {
+						res = "ERROR"
+					}
+					lines = append(lines, fmt.Sprintf("%%s\t%%s\t%%s\t%%s\t%%s", pack.Language, g.Name, rs.Name, input, res))
+				}
+			}
+		}
+		sort.Strings(lines)
+		fmt.Print(strings.Join(lines, "\n") + "\n")
+	}
+}
+`
+
+func TestGenerate(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+	if testing.Short() {
+		t.Skip("skipping build of generated source in short mode")
+	}
+
+	dir, err := ioutil.TempDir("", "testgen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	inputs := []input{}
+	exp := ""
+	for _, lang := range []string{"de", "de_CH", "en", "es", "fr", "sv", "ta"} {
+		f := filepath.Join("..", "..", "xmlreader", "test_data", lang+".xml")
+		var pack rbnf.RulePackage
+		var err error
+		if lang == "de_CH" {
+			pack, err = xmlreader.RulesFromXMLFileInherited(f)
+		} else {
+			pack, err = xmlreader.RulesFromXMLFile(f)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, input{file: f, pack: pack})
+		exp += spellouts(pack)
+	}
+
+	var src bytes.Buffer
+	if err := generate(&src, "rules", inputs); err != nil {
+		t.Fatal(err)
+	}
+	// regional files get their own constructor
+	for _, name := range []string{"De", "DeCH"} {
+		if !strings.Contains(src.String(), "\nfunc "+name+"() (rbnf.RulePackage, error) {") {
+			t.Errorf("expected constructor %s in generated source", name)
+		}
+	}
+
+	// the generated package is built in a module of its own, importing rbnf from this module
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	goMod := fmt.Sprintf(testGoMod, root)
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0644); err != nil {
+		t.Fatal(err)
+	}
+	goSum, err := ioutil.ReadFile(filepath.Join(root, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.sum"), goSum, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "rules"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "rules", "rules.go"), src.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	main := fmt.Sprintf(testMain, testInputs)
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(main), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// resolving the dependencies needs golang.org/x/text in the module cache, or network access
+	cmd := exec.Command("go", "list", "-mod=mod", "-deps", ".")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("couldn't resolve dependencies of generated source : %v\n%s", err, out)
+	}
+
+	cmd = exec.Command("go", "run", "-mod=mod", ".")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("couldn't run generated source : %v\n%s", err, out)
+	}
+	if res := string(out); res != exp {
+		expLines := strings.Split(exp, "\n")
+		resLines := strings.Split(res, "\n")
+		for i := 0; i < len(expLines) && i < len(resLines); i++ {
+			if expLines[i] != resLines[i] {
+				t.Errorf(fs, expLines[i], resLines[i])
+				break
+			}
+		}
+		if len(expLines) != len(resLines) {
+			t.Errorf(fs, len(expLines), len(resLines))
+		}
+	}
+
+	if err := generate(&src, "rules", []input{inputs[0], inputs[0]}); err == nil {
+		t.Error("expected error for duplicate language")
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/stts-se/rbnf"
+	"github.com/stts-se/rbnf/xmlreader"
+)
+
+func main() {
+
+	cmd := path.Base(os.Args[0])
+
+	// Flags
+	var flags = flag.NewFlagSet(cmd, flag.ExitOnError)
+	ruleSets := flags.String("r", "", "Comma-separated `rule sets` to spell out with (default all public rule sets)")
+	n := flags.Int("n", 1000, "Number of examples per rule set")
+	dists := flags.String("d", "", "Comma-separated `distributions`: uniform:<lo>:<hi>, year:<lo>:<hi>, loguniform:<min digits>:<max digits>,\ndecimal:<max int digits>:<fraction digits>, negative:<distribution> (default all of "+defaultDistributions()+")")
+	seed := flags.Int64("s", 1, "Random `seed`")
+	format := flags.String("f", "tsv", "Output `format` tsv or jsonl")
+	inherit := flags.Bool("i", false, "Inherit rule sets from the parent locale files in the same directory (de_CH.xml: de.xml, root.xml)")
+	help := flags.Bool("h", false, "Print usage and exit")
+	flags.Parse(os.Args[1:])
+	args := flags.Args()
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s <options> <xml files/urls>\n", cmd)
+		fmt.Fprintf(os.Stderr, "  prints spelled out numbers sampled from the distributions, with metadata\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flags.PrintDefaults()
+	}
+
+	if *help || len(args) == 0 {
+		flags.Usage()
+		os.Exit(0)
+	}
+	if *format != "tsv" && *format != "jsonl" {
+		log.Fatalf("Unknown output format: %s", *format)
+	}
+
+	opts := []rbnf.TrainingOption{rbnf.TrainingSeed(*seed)}
+	if *ruleSets != "" {
+		opts = append(opts, rbnf.TrainingRuleSets(strings.Split(*ruleSets, ",")...))
+	}
+	if *dists != "" {
+		ds := []rbnf.Distribution{}
+		for _, s := range strings.Split(*dists, ",") {
+			d, err := rbnf.ParseDistribution(s)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+			ds = append(ds, d)
+		}
+		opts = append(opts, rbnf.TrainingDistributions(ds...))
+	}
+
+	examples := []rbnf.TrainingExample{}
+	for _, f := range args {
+		var rPackage rbnf.RulePackage
+		var err error
+		if strings.HasPrefix(f, "http") {
+			rPackage, err = xmlreader.RulesFromXMLURL(f)
+		} else if *inherit {
+			rPackage, err = xmlreader.RulesFromXMLFileInherited(f)
+		} else {
+			rPackage, err = xmlreader.RulesFromXMLFile(f)
+		}
+		if err != nil {
+			log.Fatalf("Couldn't parse file %s : %v", f, err)
+		}
+		res, err := rPackage.TrainingData(*n, opts...)
+		if err != nil {
+			log.Fatalf("Couldn't generate training data for %s : %v", f, err)
+		}
+		examples = append(examples, res...)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	var err error
+	if *format == "jsonl" {
+		err = rbnf.WriteTrainingJSONL(w, examples)
+	} else {
+		err = rbnf.WriteTrainingTSV(w, examples)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		log.Fatalf("Couldn't write training data : %v", err)
+	}
+}
+
+func defaultDistributions() string {
+	names := []string{}
+	for _, d := range rbnf.DefaultDistributions {
+		names = append(names, d.Name())
+	}
+	return strings.Join(names, ",")
+}
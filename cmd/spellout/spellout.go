@@ -2,15 +2,19 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path"
+	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/stts-se/rbnf"
+	"github.com/stts-se/rbnf/icureader"
+	"github.com/stts-se/rbnf/jsonreader"
 	"github.com/stts-se/rbnf/xmlreader"
 	//
 	//"github.com/pkg/profile"
@@ -24,11 +28,17 @@ func main() {
 
 	// Flags
 	var flags = flag.NewFlagSet(cmd, flag.ExitOnError)
-	syntaxCheck := flags.Bool("s", false, "Check rule file syntax and exit")
+	syntaxCheck := flags.Bool("s", false, "Check rule file syntax and exit (and that the rule set specified by -r or -k exists)")
 	listPublicRules := flags.Bool("l", false, "List public rules and exit (rule groups and rule sets)")
 	listAllRules := flags.Bool("L", false, "List all (private/public) rules and exit (rule groups and rule sets)")
-	ruleGroup := flags.String("g", "", "Use named `rule group` (default first group)")
+	graph := flags.Bool("G", false, "Print the rule set reference graph in DOT format and exit (all rule groups, or the group specified by -g)")
+	inherit := flags.Bool("i", false, "Inherit rule sets from the parent locale files in the same directory (de_CH.xml: de.xml, root.xml)")
+	overlays := flags.String("o", "", "Apply rule overlay `files` (comma separated, applied in order)")
+	ruleGroup := flags.String("g", "", "Use named `rule group` with -r (default first group)")
 	ruleSet := flags.String("r", "", "Use named `rule set`")
+	kind := flags.String("k", "", "Use the language's rule set for numeral `kind` (cardinal, ordinal, year, digits-ordinal)")
+	printRuleSet := flags.Bool("p", false, "Print the rule group and rule set specified by -r or -k (tab separated) and exit")
+	coverage := flags.String("c", "", "Report rule coverage for the input instead of printing spellouts, in `format` text or json")
 	trimSoftHyphen := flags.Bool("t", false, "Remove soft hyphen")
 	debug := flags.Bool("d", false, "Debug")
 	help := flags.Bool("h", false, "Print usage and exit")
@@ -36,7 +46,8 @@ func main() {
 	args := flags.Args()
 
 	flags.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s <options> <xml file/url> <input>\n", cmd)
+		fmt.Fprintf(os.Stderr, "Usage: %s <options> <rule file/url> <input>\n", cmd)
+		fmt.Fprintf(os.Stderr, "  rule files are read by type: CLDR xml (.xml or url), CLDR json (.json), ICU4C resource bundle (.txt) or ICU rule description string (other)\n")
 		fmt.Fprintf(os.Stderr, "  if no input argument is specified, input will be read from stdin\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flags.PrintDefaults()
@@ -52,9 +63,19 @@ func main() {
 	f := args[0]
 
 	xmlreader.Verb = *debug
+	icureader.Verb = *debug
 
+	// the reader is selected by file type: CLDR xml, CLDR json (.json), ICU4C resource bundle (.txt) or ICU rule description string (other)
 	if strings.HasPrefix(f, "http") {
 		rPackage, err = xmlreader.RulesFromXMLURL(f)
+	} else if ext := filepath.Ext(f); ext == ".json" {
+		rPackage, err = jsonreader.RulesFromJSONFile(f)
+	} else if ext == ".txt" {
+		rPackage, err = icureader.RulesFromBundleFile(f)
+	} else if ext != ".xml" {
+		rPackage, err = icureader.RulesFromDescriptionFile(f)
+	} else if *inherit {
+		rPackage, err = xmlreader.RulesFromXMLFileInherited(f)
 	} else {
 		rPackage, err = xmlreader.RulesFromXMLFile(f)
 	}
@@ -66,7 +87,23 @@ func main() {
 		log.Printf("Parsed rule file %s", f)
 	}
 
-	if *syntaxCheck && *ruleSet == "" {
+	if *overlays != "" {
+		for _, o := range strings.Split(*overlays, ",") {
+			overlay, err := xmlreader.OverlayFromXMLFile(o)
+			if err != nil {
+				log.Fatalf("Couldn't parse overlay file %s : %v", o, err)
+			}
+			rPackage, err = rPackage.ApplyOverlay(overlay)
+			if err != nil {
+				log.Fatalf("Couldn't apply overlay file %s : %v", o, err)
+			}
+			if *debug {
+				log.Printf("Applied overlay file %s", o)
+			}
+		}
+	}
+
+	if *syntaxCheck && *ruleSet == "" && *kind == "" {
 		os.Exit(0)
 	}
 
@@ -96,12 +133,53 @@ func main() {
 		os.Exit(0)
 	}
 
-	// validate specified rule group and rule set
-	if *ruleSet == "" {
-		fmt.Fprintf(os.Stderr, "flag -r (rule set) is required\n")
+	if *graph {
+		found := false
+		for _, g := range rPackage.RuleSetGroups {
+			if *ruleGroup == "" || g.Name == *ruleGroup {
+				fmt.Print(g.Graph().DOT())
+				found = true
+			}
+		}
+		if !found {
+			log.Fatalf("Couldn't find rule group %s in rule file %s", *ruleGroup, f)
+		}
+		os.Exit(0)
+	}
+
+	// resolve rule group and rule set from numeral kind
+	if *ruleSet != "" && *kind != "" {
+		fmt.Fprintf(os.Stderr, "flags -r (rule set) and -k (kind) cannot be combined\n")
+		flags.Usage()
+		os.Exit(1)
+	}
+	// the rule group is resolved with the rule set for the kind, so -g is only used with -r
+	if *ruleSet == "" && *ruleGroup != "" {
+		fmt.Fprintf(os.Stderr, "flag -g (rule group) requires -r (rule set), and cannot be combined with -k (kind)\n")
+		flags.Usage()
+		os.Exit(1)
+	}
+	if *ruleSet == "" && *kind == "" {
+		fmt.Fprintf(os.Stderr, "flag -r (rule set) or -k (kind) is required\n")
 		flags.Usage()
 		os.Exit(1)
 	}
+	if *ruleSet == "" {
+		k, err := rbnf.ParseKind(*kind)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		g, rs, err := rPackage.RuleSetFor(k)
+		if err != nil {
+			log.Fatalf("Couldn't find rule set in rule file %s : %v", f, err)
+		}
+		ruleGroup, ruleSet = &g, &rs
+		if *debug {
+			log.Printf("Using rule set %s/%s for %s", *ruleGroup, *ruleSet, k)
+		}
+	}
+
+	// validate specified rule group and rule set
 	foundRuleGroupAndRuleSet := false
 	if *ruleGroup == "" {
 		ruleGroup = &rPackage.RuleSetGroups[0].Name
@@ -119,17 +197,41 @@ func main() {
 		log.Fatalf("Couldn't find rule set %s/%s in rule file %s", *ruleGroup, *ruleSet, f)
 	}
 
+	if *printRuleSet {
+		fmt.Printf("%s\t%s\n", *ruleGroup, *ruleSet)
+		os.Exit(0)
+	}
 	if *syntaxCheck {
 		os.Exit(0)
 	}
 
+	var cov *rbnf.Coverage
+	if *coverage != "" {
+		if *coverage != "text" && *coverage != "json" {
+			fmt.Fprintf(os.Stderr, "invalid coverage report format: %s\n", *coverage)
+			flags.Usage()
+			os.Exit(1)
+		}
+		cov = rbnf.NewCoverage()
+		rPackage.SetCoverage(cov)
+	}
+
 	var nSpelled = 0
+	var nFailed = 0
 	var process = func(s string) {
 		res, err := rPackage.Spellout(s, *ruleGroup, *ruleSet, *debug)
 		nSpelled++
+		if err != nil && cov != nil {
+			nFailed++
+			log.Printf("Couldn't spellout %s : %v", s, err)
+			return
+		}
 		if err != nil {
 			log.Fatalf("Couldn't spellout %s : %v", s, err)
 		}
+		if cov != nil {
+			return
+		}
 		if *trimSoftHyphen {
 			res = strings.Replace(res, "\u00ad", "", -1)
 		}
@@ -150,4 +252,60 @@ func main() {
 
 	///fmt.Fprintf(os.Stderr, "[%s] No of spelled numerals: %v\n", cmd, nSpelled)
 
+	if cov != nil {
+		var group rbnf.RuleSetGroup
+		for _, g := range rPackage.RuleSetGroups {
+			if g.Name == *ruleGroup {
+				group = g
+			}
+		}
+		report := cov.Report(group)
+		if *coverage == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(report); err != nil {
+				log.Fatalf("Couldn't marshal coverage report : %v", err)
+			}
+		} else {
+			printCoverage(report, nSpelled, nFailed)
+		}
+	}
+}
+
+func printCoverage(report rbnf.CoverageReport, nSpelled int, nFailed int) {
+	ruleSets, ruleSetsHit, rules, rulesHit, subs, subsHit := report.Totals()
+	fmt.Println("== Coverage ==")
+	fmt.Printf("Input: %d (%d failed)\n", nSpelled, nFailed)
+	fmt.Printf("Rule sets: %d/%d\n", ruleSetsHit, ruleSets)
+	fmt.Printf("Rules: %d/%d\n", rulesHit, rules)
+	fmt.Printf("Subs: %d/%d\n", subsHit, subs)
+
+	access := func(rs rbnf.RuleSetCoverage) string {
+		if rs.Private {
+			return "private"
+		}
+		return "public"
+	}
+	fmt.Println("== Unexercised rule sets ==")
+	for _, rs := range report.UnexercisedRuleSets() {
+		fmt.Printf(" - %s [%s] (%d)\n", rs.RuleSet, access(rs), len(rs.Rules))
+	}
+	fmt.Println("== Unexercised rules ==")
+	for _, rs := range report.UnexercisedRules() {
+		fmt.Printf("%s [%s]\n", rs.RuleSet, access(rs))
+		for _, r := range rs.Rules {
+			fmt.Printf(" - #%d %s\n", r.Index, r.Rule)
+		}
+	}
+	fmt.Println("== Unexercised subs ==")
+	for _, rs := range report.UnexercisedSubs() {
+		fmt.Printf("%s [%s]\n", rs.RuleSet, access(rs))
+		for _, r := range rs.Rules {
+			for _, sub := range r.Subs {
+				fmt.Printf(" - #%d %s : sub #%d %s\n", r.Index, r.Rule, sub.Index, sub.Sub)
+			}
+		}
+	}
+
 }
@@ -41,16 +41,9 @@ for lang in $langs; do
     outgo="$outdir/${lang}_${numsfile}_rbnfgo.txt"
     outdiff="$outdir/${lang}_${numsfile}.diff"
 
-    # rule set name
-    ruleset="spellout-numbering"
-    if [ $lang == "da" ]; then
-	ruleset="spellout-cardinal-common"
-    fi
-    echo "Rule set: $ruleset" 1>&2
-
     
     time cat ${numsfile}.txt | scala -cp ${icu4j_jar} batch_run_icu4j_cardinal.scala ${lang} >| $outicu4j
-    if time cat ${numsfile}.txt | go run ../cmd/spellout/spellout.go -r $ruleset https://github.com/unicode-org/cldr/raw/master/common/rbnf/${lang}.xml >| $outgo ; then
+    if time cat ${numsfile}.txt | go run ../cmd/spellout/spellout.go -k cardinal https://github.com/unicode-org/cldr/raw/master/common/rbnf/${lang}.xml >| $outgo ; then
 	compare_line_by_line -q $outgo $outicu4j &> $outdiff
 	echo "=== $lang DONE" 1>&2
     else
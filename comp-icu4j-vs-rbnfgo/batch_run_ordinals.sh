@@ -29,8 +29,6 @@ mkdir -p $outdir
 
 all_langs="af ak am ar az be ca ccp da de de_CH ee el en en_IN eo es ff fi fil fr fr_BE fr_CH he hi hr hu id it ja km ko ky lb lo ms my nb nl pt pt_PT qu ru sr sr_Latn sv sw ta th tr vi yue yue_Hans zh"
 
-declare -A rulesets=( ["ar"]="spellout-ordinal-feminine" ["be"]="spellout-ordinal-feminine" ["ca"]="spellout-ordinal-feminine" ["da"]="spellout-ordinal-common" ["el"]="spellout-ordinal-feminine" ["es"]="spellout-ordinal-feminine" ["fr"]="spellout-ordinal-feminine" ["fr_BE"]="spellout-ordinal-feminine" ["fr_CH"]="spellout-ordinal-feminine" ["he"]="spellout-ordinal-feminine" ["hi"]="spellout-ordinal-feminine" ["hr"]="spellout-ordinal-feminine" ["it"]="spellout-ordinal-feminine" ["ko"]="spellout-ordinal-native" ["lb"]="spellout-ordinal-feminine" ["nb"]="spellout-ordinal-feminine" ["pt"]="spellout-ordinal-feminine" ["pt_PT"]="spellout-ordinal-feminine" ["ru"]="spellout-ordinal-feminine" ["sv"]="spellout-ordinal-feminine" )
-
 langs=$all_langs
 #langs="sv en de fr da fi es"
 
@@ -46,18 +44,21 @@ for lang in $langs; do
     outgo="$outdir/${lang}_${numsfile}_rbnfgo.txt"
     outdiff="$outdir/${lang}_${numsfile}.diff"
 
-    # rule set name
-    ruleset=${rulesets[$lang]}
-    if [ "<$ruleset>" == "<>" ]; then
-	ruleset="spellout-ordinal"
+    url=https://github.com/unicode-org/cldr/raw/master/common/rbnf/${lang}.xml
+
+    # rule set name, as resolved by spellout -k ordinal (needed by icu4j)
+    if ! resolved=$(go run ../cmd/spellout/spellout.go -p -k ordinal $url); then
+	echo "=== $lang FAILED: no ordinal rule set" 1>&2
+	continue
     fi
+    ruleset=$(echo "$resolved" | cut -f2)
     echo "Rule set: $ruleset" 1>&2
 
     # list ordinal rule sets:
     # go run ../cmd/spellout/spellout.go -l https://github.com/unicode-org/cldr/raw/master/common/rbnf/${lang}.xml | egrep "spellout-ordinal" | head -1 | sed "s/^/${lang}\t/" | sed 's/ - //' | sed 's/ .public.*//' | egrep -v "spellout-ordinal$" 
     
     time cat ${numsfile}.txt | scala -cp ${icu4j_jar} batch_run_icu4j_ordinal.scala ${lang} ${ruleset} >| $outicu4j
-    if time cat ${numsfile}.txt | go run ../cmd/spellout/spellout.go -r $ruleset https://github.com/unicode-org/cldr/raw/master/common/rbnf/${lang}.xml >| $outgo ; then
+    if time cat ${numsfile}.txt | go run ../cmd/spellout/spellout.go -k ordinal $url >| $outgo ; then
     	compare_line_by_line -q $outgo $outicu4j &> $outdiff
     	echo "=== $lang DONE" 1>&2
     else
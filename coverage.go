@@ -0,0 +1,210 @@
+package rbnf
+
+import (
+	"sort"
+	"sync"
+)
+
+type ruleKey struct {
+	group   string
+	ruleSet string
+	rule    int
+}
+
+type subKey struct {
+	ruleKey
+	sub int
+}
+
+// Coverage collects hits per rule set, rule and sub during spellout. It is attached to rule set groups
+// using RulePackage.SetCoverage (or the Coverage field of RuleSetGroup), and is safe for concurrent use.
+type Coverage struct {
+	mu       sync.Mutex
+	ruleSets map[[2]string]int
+	rules    map[ruleKey]int
+	subs     map[subKey]int
+}
+
+// NewCoverage creates an empty coverage collector
+func NewCoverage() *Coverage {
+	return &Coverage{
+		ruleSets: make(map[[2]string]int),
+		rules:    make(map[ruleKey]int),
+		subs:     make(map[subKey]int),
+	}
+}
+
+func (c *Coverage) hitRuleSet(group string, ruleSet string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ruleSets[[2]string{group, ruleSet}]++
+}
+
+func (c *Coverage) hitRule(group string, ruleSet string, rule int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rules[ruleKey{group, ruleSet, rule}]++
+}
+
+func (c *Coverage) hitSub(group string, ruleSet string, rule int, sub int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subs[subKey{ruleKey{group, ruleSet, rule}, sub}]++
+}
+
+// Reset removes all collected hits
+func (c *Coverage) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ruleSets = make(map[[2]string]int)
+	c.rules = make(map[ruleKey]int)
+	c.subs = make(map[subKey]int)
+}
+
+// SetCoverage attaches the coverage collector to all rule set groups in the package (nil to detach it)
+func (r *RulePackage) SetCoverage(c *Coverage) {
+	for i := range r.RuleSetGroups {
+		r.RuleSetGroups[i].Coverage = c
+	}
+}
+
+// SubCoverage holds the number of times a sub was used. Optional subs omitted for the input are not counted.
+type SubCoverage struct {
+	Index int
+	Sub   string
+	Hits  int
+}
+
+// RuleCoverage holds the number of times a rule was matched, and the coverage of its subs
+type RuleCoverage struct {
+	Index int
+	Rule  string
+	Hits  int
+	Subs  []SubCoverage
+}
+
+// RuleSetCoverage holds the number of times a rule set was called, and the coverage of its rules
+type RuleSetCoverage struct {
+	Group   string
+	RuleSet string
+	Private bool
+	Hits    int
+	Rules   []RuleCoverage
+}
+
+// CoverageReport holds the coverage for each rule set, sorted by group and rule set name
+type CoverageReport struct {
+	RuleSets []RuleSetCoverage
+}
+
+// Report returns the coverage of the rule sets in the groups
+func (c *Coverage) Report(groups ...RuleSetGroup) CoverageReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := CoverageReport{}
+	for _, g := range groups {
+		names := []string{}
+		for name := range g.RuleSets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			rs := g.RuleSets[name]
+			rsc := RuleSetCoverage{Group: g.Name, RuleSet: name, Private: rs.Private, Hits: c.ruleSets[[2]string{g.Name, name}]}
+			for i, rule := range rs.Rules {
+				key := ruleKey{g.Name, name, i}
+				rc := RuleCoverage{Index: i, Rule: rule.String(), Hits: c.rules[key]}
+				for j, sub := range rule.Subs {
+					rc.Subs = append(rc.Subs, SubCoverage{Index: j, Sub: sub.String(), Hits: c.subs[subKey{key, j}]})
+				}
+				rsc.Rules = append(rsc.Rules, rc)
+			}
+			res.RuleSets = append(res.RuleSets, rsc)
+		}
+	}
+	return res
+}
+
+// UnexercisedRuleSets returns the rule sets that were never called
+func (r CoverageReport) UnexercisedRuleSets() []RuleSetCoverage {
+	res := []RuleSetCoverage{}
+	for _, rs := range r.RuleSets {
+		if rs.Hits == 0 {
+			res = append(res, rs)
+		}
+	}
+	return res
+}
+
+// UnexercisedRules returns the rules that were never matched, in rule sets that were called.
+// Rules of unexercised rule sets are not included.
+func (r CoverageReport) UnexercisedRules() []RuleSetCoverage {
+	res := []RuleSetCoverage{}
+	for _, rs := range r.RuleSets {
+		if rs.Hits == 0 {
+			continue
+		}
+		rules := []RuleCoverage{}
+		for _, rule := range rs.Rules {
+			if rule.Hits == 0 {
+				rules = append(rules, rule)
+			}
+		}
+		if len(rules) > 0 {
+			rs.Rules = rules
+			res = append(res, rs)
+		}
+	}
+	return res
+}
+
+// UnexercisedSubs returns the matched rules with subs that were never used (typically optional subs)
+func (r CoverageReport) UnexercisedSubs() []RuleSetCoverage {
+	res := []RuleSetCoverage{}
+	for _, rs := range r.RuleSets {
+		rules := []RuleCoverage{}
+		for _, rule := range rs.Rules {
+			if rule.Hits == 0 {
+				continue
+			}
+			subs := []SubCoverage{}
+			for _, sub := range rule.Subs {
+				if sub.Hits == 0 {
+					subs = append(subs, sub)
+				}
+			}
+			if len(subs) > 0 {
+				rule.Subs = subs
+				rules = append(rules, rule)
+			}
+		}
+		if len(rules) > 0 {
+			rs.Rules = rules
+			res = append(res, rs)
+		}
+	}
+	return res
+}
+
+// Totals returns the number of rule sets, rules and subs, and the number of them that were exercised
+func (r CoverageReport) Totals() (ruleSets, ruleSetsHit, rules, rulesHit, subs, subsHit int) {
+	for _, rs := range r.RuleSets {
+		ruleSets++
+		if rs.Hits > 0 {
+			ruleSetsHit++
+		}
+		for _, rule := range rs.Rules {
+			rules++
+			if rule.Hits > 0 {
+				rulesHit++
+			}
+			for _, sub := range rule.Subs {
+				subs++
+				if sub.Hits > 0 {
+					subsHit++
+				}
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,90 @@
+package rbnf
+
+import (
+	"testing"
+)
+
+func TestCoverage(t *testing.T) {
+	lang := Language("sv")
+	numbering := RuleSet{
+		Name: "spellout-numbering",
+		Rules: []BaseRule{
+			NewIntRule(lang, 0, 10, "noll"),
+			NewIntRule(lang, 1, 10, "ett"),
+			NewIntRule(lang, 2, 10, "två"),
+			NewIntRule(lang, 3, 10, "tre"),
+			NewIntRule(lang, 10, 10, "tio"),
+			NewIntRule(lang, 20, 10, "tjugo", "[>>]"),
+		},
+	}
+	ordinal := RuleSet{
+		Name: "spellout-ordinal",
+		Rules: []BaseRule{
+			NewIntRule(lang, 0, 10, "nollte"),
+		},
+	}
+	g, err := NewRuleSetGroup("SpelloutRules", lang, []RuleSet{numbering, ordinal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := NewRulePackage(lang, []RuleSetGroup{g}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cov := NewCoverage()
+	pkg.SetCoverage(cov)
+	for _, input := range []string{"1", "2", "20"} {
+		if _, err := pkg.Spellout(input, "SpelloutRules", "spellout-numbering", false); err != nil {
+			t.Error(err)
+		}
+	}
+
+	report := cov.Report(pkg.RuleSetGroups...)
+	ruleSets, ruleSetsHit, rules, rulesHit, subs, subsHit := report.Totals()
+	if exp, res := [6]int{2, 1, 7, 3, 8, 3}, [6]int{ruleSets, ruleSetsHit, rules, rulesHit, subs, subsHit}; exp != res {
+		t.Errorf(fs, exp, res)
+	}
+
+	unRuleSets := report.UnexercisedRuleSets()
+	if len(unRuleSets) != 1 {
+		t.Errorf(fs, 1, len(unRuleSets))
+	} else if exp, res := "spellout-ordinal", unRuleSets[0].RuleSet; exp != res {
+		t.Errorf(fs, exp, res)
+	}
+
+	unRules := report.UnexercisedRules()
+	if len(unRules) != 1 {
+		t.Errorf(fs, 1, len(unRules))
+	} else {
+		var res []int
+		for _, r := range unRules[0].Rules {
+			res = append(res, r.Index)
+		}
+		if exp := []int{0, 3, 4}; len(exp) != len(res) || exp[0] != res[0] || exp[1] != res[1] || exp[2] != res[2] {
+			t.Errorf(fs, exp, res)
+		}
+	}
+
+	// the optional sub of rule 20 was omitted
+	unSubs := report.UnexercisedSubs()
+	if len(unSubs) != 1 || len(unSubs[0].Rules) != 1 {
+		t.Errorf("unexpected unexercised subs: %#v", unSubs)
+	} else if exp, res := "[>>]", unSubs[0].Rules[0].Subs[0].Sub; exp != res {
+		t.Errorf(fs, exp, res)
+	}
+
+	if res, err := pkg.Spellout("22", "SpelloutRules", "spellout-numbering", false); err != nil {
+		t.Error(err)
+	} else if exp := "tjugotvå"; res != exp {
+		t.Errorf(fs, exp, res)
+	}
+	if res := cov.Report(pkg.RuleSetGroups...).UnexercisedSubs(); len(res) != 0 {
+		t.Errorf("expected no unexercised subs, got %#v", res)
+	}
+
+	cov.Reset()
+	if _, ruleSetsHit, _, _, _, _ := cov.Report(pkg.RuleSetGroups...).Totals(); ruleSetsHit != 0 {
+		t.Errorf(fs, 0, ruleSetsHit)
+	}
+}
@@ -0,0 +1,177 @@
+package rbnf
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ChangeType is the type of a change between two rule packages
+type ChangeType string
+
+const (
+	// ChangeAdded is a rule set group, rule set or rule found only in the new package
+	ChangeAdded ChangeType = "added"
+	// ChangeRemoved is a rule set group, rule set or rule found only in the old package
+	ChangeRemoved ChangeType = "removed"
+	// ChangeModified is a rule with the same base value, but different subs or radix
+	ChangeModified ChangeType = "modified"
+	// ChangeAccess is a rule set changed from public to private, or the other way around
+	ChangeAccess ChangeType = "access"
+)
+
+// Change is a difference between two rule packages, keyed by group, rule set and base value (see Diff).
+// For group changes, RuleSet and Base are empty, and for rule set changes, Base is empty.
+// Old and New hold the old and new rule (BaseRule.String), or the access (public or private) for ChangeAccess.
+type Change struct {
+	Type    ChangeType
+	Group   string
+	RuleSet string
+	Base    string
+	Old     string
+	New     string
+}
+
+func (c Change) String() string {
+	key := c.Group
+	what := "rule set group"
+	if c.RuleSet != "" {
+		key += "/" + c.RuleSet
+		what = "rule set"
+	}
+	if c.Base != "" {
+		key += "/" + c.Base
+		what = "rule"
+	}
+	switch c.Type {
+	case ChangeAdded:
+		if c.New != "" {
+			return fmt.Sprintf("added %s %s: %s", what, key, c.New)
+		}
+		return fmt.Sprintf("added %s %s", what, key)
+	case ChangeRemoved:
+		if c.Old != "" {
+			return fmt.Sprintf("removed %s %s: %s", what, key, c.Old)
+		}
+		return fmt.Sprintf("removed %s %s", what, key)
+	case ChangeAccess:
+		return fmt.Sprintf("changed access of %s %s: %s => %s", what, key, c.Old, c.New)
+	default:
+		return fmt.Sprintf("modified %s %s: old %s, new %s", what, key, c.Old, c.New)
+	}
+}
+
+func ruleSetAccess(rs RuleSet) string {
+	if rs.Private {
+		return "private"
+	}
+	return "public"
+}
+
+// ruleKeys returns the base value of each rule, used to pair the rules of two rule sets. Repeated base
+// values are numbered by occurrence: 0, 0 (2).
+func ruleKeys(rules []BaseRule) []string {
+	res := []string{}
+	seen := make(map[string]int)
+	for _, r := range rules {
+		key := r.Base.Value()
+		seen[key]++
+		if n := seen[key]; n > 1 {
+			key = fmt.Sprintf("%s (%d)", key, n)
+		}
+		res = append(res, key)
+	}
+	return res
+}
+
+func diffRuleSets(group string, a, b RuleSet) []Change {
+	res := []Change{}
+	if a.Private != b.Private {
+		res = append(res, Change{Type: ChangeAccess, Group: group, RuleSet: a.Name, Old: ruleSetAccess(a), New: ruleSetAccess(b)})
+	}
+	aKeys, bKeys := ruleKeys(a.Rules), ruleKeys(b.Rules)
+	bRules := make(map[string]BaseRule)
+	for i, key := range bKeys {
+		bRules[key] = b.Rules[i]
+	}
+	aRules := make(map[string]bool)
+	for i, key := range aKeys {
+		aRules[key] = true
+		old := a.Rules[i]
+		updated, ok := bRules[key]
+		if !ok {
+			res = append(res, Change{Type: ChangeRemoved, Group: group, RuleSet: a.Name, Base: key, Old: old.String()})
+		} else if old.String() != updated.String() {
+			res = append(res, Change{Type: ChangeModified, Group: group, RuleSet: a.Name, Base: key, Old: old.String(), New: updated.String()})
+		}
+	}
+	for i, key := range bKeys {
+		if !aRules[key] {
+			res = append(res, Change{Type: ChangeAdded, Group: group, RuleSet: b.Name, Base: key, New: b.Rules[i].String()})
+		}
+	}
+	return res
+}
+
+func sortedRuleSetNames(ruleSets ...map[string]RuleSet) []string {
+	res := []string{}
+	seen := make(map[string]bool)
+	for _, rss := range ruleSets {
+		for name := range rss {
+			if !seen[name] {
+				seen[name] = true
+				res = append(res, name)
+			}
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Diff returns the structural changes from rule package a to rule package b: rule set groups and rule sets
+// added or removed, rule sets changing access, and rules added, removed or modified. Rules are paired by
+// base value, so a rule whose subs were changed is reported as modified, not as removed and added. A rule
+// set group added or removed is reported as a single change, without its rule sets. Changes are ordered
+// by group (in order of appearance), rule set name and rule order.
+func Diff(a, b RulePackage) []Change {
+	res := []Change{}
+	groupNames := []string{}
+	aGroups := make(map[string]RuleSetGroup)
+	bGroups := make(map[string]RuleSetGroup)
+	for _, g := range a.RuleSetGroups {
+		groupNames = append(groupNames, g.Name)
+		aGroups[g.Name] = g
+	}
+	for _, g := range b.RuleSetGroups {
+		if _, ok := aGroups[g.Name]; !ok {
+			groupNames = append(groupNames, g.Name)
+		}
+		bGroups[g.Name] = g
+	}
+
+	for _, name := range groupNames {
+		ag, aOk := aGroups[name]
+		bg, bOk := bGroups[name]
+		// the rule sets of a group added or removed are not listed
+		if !bOk {
+			res = append(res, Change{Type: ChangeRemoved, Group: name})
+			continue
+		}
+		if !aOk {
+			res = append(res, Change{Type: ChangeAdded, Group: name})
+			continue
+		}
+		for _, rsName := range sortedRuleSetNames(ag.RuleSets, bg.RuleSets) {
+			ars, aOk := ag.RuleSets[rsName]
+			brs, bOk := bg.RuleSets[rsName]
+			switch {
+			case !bOk:
+				res = append(res, Change{Type: ChangeRemoved, Group: name, RuleSet: rsName})
+			case !aOk:
+				res = append(res, Change{Type: ChangeAdded, Group: name, RuleSet: rsName})
+			default:
+				res = append(res, diffRuleSets(name, ars, brs)...)
+			}
+		}
+	}
+	return res
+}
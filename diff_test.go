@@ -0,0 +1,116 @@
+package rbnf
+
+import (
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	lang := Language("sv")
+	numbering := RuleSet{
+		Name: "spellout-numbering",
+		Rules: []BaseRule{
+			NewStringRule(lang, "-x", "minus ", ">>"),
+			NewIntRule(lang, 0, 10, "noll"),
+			NewIntRule(lang, 1, 10, "ett"),
+			NewIntRule(lang, 2, 10, "två"),
+			NewIntRule(lang, 20, 10, "tjugo", "[>>]"),
+		},
+	}
+	year := RuleSet{
+		Name:  "spellout-numbering-year",
+		Rules: []BaseRule{NewIntRule(lang, 0, 10, "=%spellout-numbering=")},
+	}
+	g, err := NewRuleSetGroup("SpelloutRules", lang, []RuleSet{numbering, year})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewRulePackage(lang, []RuleSetGroup{g}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res := Diff(a, a); len(res) != 0 {
+		t.Errorf("expected no changes, got %v", res)
+	}
+
+	numbering2 := RuleSet{
+		Name: "spellout-numbering",
+		Rules: []BaseRule{
+			NewIntRule(lang, 0, 10, "noll"),
+			NewIntRule(lang, 1, 10, "en"),
+			NewIntRule(lang, 2, 10, "två"),
+			NewIntRule(lang, 3, 10, "tre"),
+			NewIntRule(lang, 20, 10, "tjugo", "[>>]"),
+		},
+	}
+	year2 := year
+	year2.Private = true
+	ordinal := RuleSet{
+		Name:  "spellout-ordinal",
+		Rules: []BaseRule{NewIntRule(lang, 1, 10, "första")},
+	}
+	g2, err := NewRuleSetGroup("SpelloutRules", lang, []RuleSet{numbering2, year2, ordinal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	digits := RuleSet{
+		Name:  "digits-ordinal",
+		Rules: []BaseRule{NewIntRule(lang, 0, 10, "=#,##0=", ":e")},
+	}
+	g3, err := NewRuleSetGroup("OrdinalRules", lang, []RuleSet{digits})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewRulePackage(lang, []RuleSetGroup{g2, g3}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []string{
+		"removed rule SpelloutRules/spellout-numbering/-x: -x => 'minus >>'",
+		"modified rule SpelloutRules/spellout-numbering/1: old 1 (10) => 'ett', new 1 (10) => 'en'",
+		"added rule SpelloutRules/spellout-numbering/3: 3 (10) => 'tre'",
+		"changed access of rule set SpelloutRules/spellout-numbering-year: public => private",
+		"added rule set SpelloutRules/spellout-ordinal",
+		"added rule set group OrdinalRules",
+	}
+	res := Diff(a, b)
+	if len(res) != len(exp) {
+		t.Errorf(fs, exp, res)
+		return
+	}
+	for i, c := range res {
+		if c.String() != exp[i] {
+			t.Errorf(fs, exp[i], c.String())
+		}
+	}
+
+	// removals are the reverse of additions
+	res = Diff(b, a)
+	if exp, got := "added rule SpelloutRules/spellout-numbering/-x: -x => 'minus >>'", res[2].String(); exp != got {
+		t.Errorf(fs, exp, got)
+	}
+	// a group removed is a single change
+	if exp, got := "removed rule set group OrdinalRules", res[len(res)-1].String(); exp != got {
+		t.Errorf(fs, exp, got)
+	}
+	if exp, got := len(Diff(a, b)), len(res); exp != got {
+		t.Errorf(fs, exp, got)
+	}
+}
+
+func TestRuleKeys(t *testing.T) {
+	lang := Language("sv")
+	rules := []BaseRule{
+		NewStringRule(lang, "x.x", "<<", " komma ", ">>"),
+		NewIntRule(lang, 0, 10, "noll"),
+		NewIntRule(lang, 0, 10, "nolla"),
+	}
+	exp := []string{"x.x", "0", "0 (2)"}
+	res := ruleKeys(rules)
+	for i := range exp {
+		if exp[i] != res[i] {
+			t.Errorf(fs, exp[i], res[i])
+		}
+	}
+}
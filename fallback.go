@@ -0,0 +1,139 @@
+package rbnf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// NoMatchError is returned when no rule in a rule set matches the input
+type NoMatchError struct {
+	Input   string
+	RuleSet string
+}
+
+func (e *NoMatchError) Error() string {
+	return fmt.Sprintf("no matching base rule for %s in rule set %s", e.Input, e.RuleSet)
+}
+
+// Fallback is a strategy for input not matched by any rule
+type Fallback string
+
+const (
+	// FallbackFail returns an error (default)
+	FallbackFail Fallback = ""
+	// FallbackRuleSet spells out the input using another rule set
+	FallbackRuleSet Fallback = "rule-set"
+	// FallbackDigits spells out the input digit by digit (input of digits only)
+	FallbackDigits Fallback = "digits"
+	// FallbackPassThrough returns the input unchanged
+	FallbackPassThrough Fallback = "pass-through"
+)
+
+// FallbackPolicy defines what to do when no rule matches the input
+type FallbackPolicy struct {
+	Fallback Fallback
+	// RuleSet is the rule set used by FallbackRuleSet, and for digit names by FallbackDigits.
+	// For FallbackDigits, it defaults to the package's cardinal rule set (see RuleSetFor).
+	RuleSet string
+}
+
+// SpelloutResult is the result of a spellout, including the fallback applied (FallbackFail if the input was matched by the rules)
+type SpelloutResult struct {
+	Output   string
+	Fallback Fallback
+}
+
+func (r *RulePackage) fallbackPolicy(ruleSetName string) FallbackPolicy {
+	if p, ok := r.RuleSetFallbacks[ruleSetName]; ok {
+		return p
+	}
+	return r.Fallback
+}
+
+// findRuleSet returns the group for the named rule set, preferring the named group
+func (r *RulePackage) findRuleSet(preferredGroupName string, ruleSetName string) (string, bool) {
+	for _, g := range r.RuleSetGroups {
+		if _, ok := g.FindRuleSet(ruleSetName); ok && g.Name == preferredGroupName {
+			return g.Name, true
+		}
+	}
+	return r.findGroupWithRuleSet(ruleSetName)
+}
+
+// SpelloutWithResult spells out the input string using the named rule set group and rule set. If no rule of the
+// rule set matches the input, the fallback policy for the rule set is applied (RuleSetFallbacks, or Fallback if the
+// rule set has no policy of its own), and the result reports which fallback was used. No-matches in rule sets
+// referenced by the rule set are returned as errors.
+func (r *RulePackage) SpelloutWithResult(input string, groupName string, ruleSetName string, debug bool) (SpelloutResult, error) {
+	res, err := r.spellout(input, groupName, ruleSetName, debug)
+	if err == nil {
+		return SpelloutResult{Output: res}, nil
+	}
+	var noMatch *NoMatchError
+	if !errors.As(err, &noMatch) || noMatch.RuleSet != ruleSetName {
+		return SpelloutResult{}, err
+	}
+	policy := r.fallbackPolicy(ruleSetName)
+	if debug && policy.Fallback != FallbackFail {
+		fmt.Fprintf(os.Stderr, "[rbnf] Applying fallback %s for input %s (%v)\n", policy.Fallback, input, err)
+	}
+	switch policy.Fallback {
+	case FallbackFail:
+		return SpelloutResult{}, err
+	case FallbackPassThrough:
+		return SpelloutResult{Output: input, Fallback: policy.Fallback}, nil
+	case FallbackRuleSet:
+		fbGroupName, ok := r.findRuleSet(groupName, policy.RuleSet)
+		if !ok {
+			return SpelloutResult{}, fmt.Errorf("no such fallback rule set: %s", policy.RuleSet)
+		}
+		res, fbErr := r.spellout(input, fbGroupName, policy.RuleSet, debug)
+		if fbErr != nil {
+			return SpelloutResult{}, fmt.Errorf("fallback rule set %s failed : %v", policy.RuleSet, fbErr)
+		}
+		return SpelloutResult{Output: res, Fallback: policy.Fallback}, nil
+	case FallbackDigits:
+		res, fbErr := r.spelloutDigits(input, groupName, policy.RuleSet, debug)
+		if fbErr != nil {
+			return SpelloutResult{}, fmt.Errorf("digit fallback failed : %v", fbErr)
+		}
+		return SpelloutResult{Output: res, Fallback: policy.Fallback}, nil
+	default:
+		return SpelloutResult{}, fmt.Errorf("unknown fallback: %s", policy.Fallback)
+	}
+}
+
+// spelloutDigits spells out each decimal digit of the input separately. Input with other characters than digits
+// and spaces is rejected.
+func (r *RulePackage) spelloutDigits(input string, preferredGroupName string, ruleSetName string, debug bool) (string, error) {
+	groupName := preferredGroupName
+	if ruleSetName == "" {
+		var err error
+		groupName, ruleSetName, err = r.RuleSetFor(Cardinal)
+		if err != nil {
+			return "", err
+		}
+	} else if g, ok := r.findRuleSet(preferredGroupName, ruleSetName); ok {
+		groupName = g
+	} else {
+		return "", fmt.Errorf("no such rule set: %s", ruleSetName)
+	}
+	res := []string{}
+	for _, ch := range input {
+		if unicode.IsSpace(ch) {
+			continue
+		}
+		if ch < '0' || ch > '9' {
+			return "", fmt.Errorf("not a digit: %q", ch)
+		}
+		s, err := r.spellout(string(ch), groupName, ruleSetName, debug)
+		if err != nil {
+			return "", err
+		}
+		res = append(res, s)
+	}
+	return strings.Join(res, " "), nil
+}
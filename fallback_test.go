@@ -0,0 +1,163 @@
+package rbnf
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFallbackPolicy(t *testing.T) {
+	lang := Language("sv")
+	numbering := RuleSet{
+		Name: "spellout-numbering",
+		Rules: []BaseRule{
+			NewIntRule(lang, 0, 10, "noll"),
+			NewIntRule(lang, 1, 10, "ett"),
+			NewIntRule(lang, 2, 10, "två"),
+			NewIntRule(lang, 3, 10, "tre"),
+			NewIntRule(lang, 4, 10, "fyra"),
+			NewIntRule(lang, 5, 10, "fem"),
+			NewIntRule(lang, 6, 10, "sex"),
+			NewIntRule(lang, 7, 10, "sju"),
+			NewIntRule(lang, 8, 10, "åtta"),
+			NewIntRule(lang, 9, 10, "nio"),
+			NewIntRule(lang, 10, 10, "tio"),
+			NewIntRule(lang, 11, 10, "elva"),
+			NewIntRule(lang, 12, 10, "tolv"),
+			NewIntRule(lang, 13, 10, "=#,##0="),
+		},
+	}
+	small := RuleSet{
+		Name: "small",
+		Rules: []BaseRule{
+			NewIntRule(lang, 0, 10, "=%spellout-numbering="),
+			NewIntRule(lang, 11, 10, "ERROR"),
+		},
+	}
+	negative := RuleSet{
+		Name: "negative",
+		Rules: []BaseRule{
+			NewStringRule(lang, "-x", "minus", " ", ">%spellout-numbering>"),
+		},
+	}
+	g, err := NewRuleSetGroup("SpelloutRules", lang, []RuleSet{numbering, small, negative})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := NewRulePackage(lang, []RuleSetGroup{g}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var res SpelloutResult
+
+	// default: fail
+	_, err = pkg.SpelloutWithResult("-12", "SpelloutRules", "small", false)
+	var noMatch *NoMatchError
+	if !errors.As(err, &noMatch) {
+		t.Errorf("expected NoMatchError, got %v", err)
+	}
+
+	// global pass through
+	pkg.Fallback = FallbackPolicy{Fallback: FallbackPassThrough}
+	res, err = pkg.SpelloutWithResult("-12", "SpelloutRules", "small", false)
+	if exp := (SpelloutResult{Output: "-12", Fallback: FallbackPassThrough}); err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	// no fallback for matched input
+	res, err = pkg.SpelloutWithResult("12", "SpelloutRules", "spellout-numbering", false)
+	if exp := (SpelloutResult{Output: "tolv"}); err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	// other errors are not subject to fallback
+	_, err = pkg.SpelloutWithResult("12", "SpelloutRules", "small", false)
+	if err == nil {
+		t.Error("expected error here")
+	}
+
+	// per rule set: digits
+	pkg.RuleSetFallbacks = map[string]FallbackPolicy{"small": {Fallback: FallbackDigits}}
+	pkg.RuleSetFallbacks = map[string]FallbackPolicy{"small": {Fallback: FallbackDigits}, "negative": {Fallback: FallbackDigits, RuleSet: "spellout-numbering"}}
+	res, err = pkg.SpelloutWithResult("12", "SpelloutRules", "negative", false)
+	if exp := (SpelloutResult{Output: "ett två", Fallback: FallbackDigits}); err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+	res, err = pkg.SpelloutWithResult("-12", "SpelloutRules", "small", false)
+	if err == nil {
+		t.Errorf("expected error here, got %v", res)
+	}
+
+	// per rule set: rule set
+	pkg.RuleSetFallbacks = map[string]FallbackPolicy{"spellout-numbering": {Fallback: FallbackRuleSet, RuleSet: "small"}}
+	_, err = pkg.SpelloutWithResult("-2", "SpelloutRules", "spellout-numbering", false)
+	if err == nil {
+		t.Error("expected error here")
+	}
+	pkg.RuleSetFallbacks = map[string]FallbackPolicy{"small": {Fallback: FallbackRuleSet, RuleSet: "negative"}}
+	res, err = pkg.SpelloutWithResult("-2", "SpelloutRules", "small", false)
+	if exp := (SpelloutResult{Output: "minus två", Fallback: FallbackRuleSet}); err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+	res, err = pkg.SpelloutWithResult("1.5", "SpelloutRules", "small", false)
+	if err == nil {
+		t.Errorf("expected error here, got %v", res)
+	}
+
+	pkg.RuleSetFallbacks = map[string]FallbackPolicy{"small": {Fallback: FallbackDigits, RuleSet: "spellout-numbering"}}
+	if s, err := pkg.Spellout("1.5", "SpelloutRules", "small", false); err == nil {
+		t.Errorf("expected error here, got %v", s)
+	}
+}
+
+func TestFallbackPolicyNested(t *testing.T) {
+	lang := Language("sv")
+	inner := RuleSet{
+		Name:    "inner",
+		Private: true,
+		Rules: []BaseRule{
+			NewStringRule(lang, "x.x", "komma"),
+		},
+	}
+	outer := RuleSet{
+		Name: "outer",
+		Rules: []BaseRule{
+			NewStringRule(lang, "-x", "minus", " ", ">%%inner>"),
+			NewIntRule(lang, 0, 10, "=%%inner="),
+		},
+	}
+	g, err := NewRuleSetGroup("SpelloutRules", lang, []RuleSet{inner, outer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := NewRulePackage(lang, []RuleSetGroup{g}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg.Fallback = FallbackPolicy{Fallback: FallbackPassThrough}
+
+	// no rule of outer matches
+	res, err := pkg.SpelloutWithResult("1.5", "SpelloutRules", "outer", false)
+	if exp := (SpelloutResult{Output: "1.5", Fallback: FallbackPassThrough}); err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	// outer matches, but not the rule set it refers to
+	for _, input := range []string{"-2", "2"} {
+		res, err = pkg.SpelloutWithResult(input, "SpelloutRules", "outer", false)
+		var noMatch *NoMatchError
+		if !errors.As(err, &noMatch) || noMatch.RuleSet != "inner" {
+			t.Errorf("expected NoMatchError for rule set inner, got %v (%v)", err, res)
+		}
+	}
+}
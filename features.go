@@ -0,0 +1,279 @@
+package rbnf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Features is a bundle of grammatical features, either parsed from a CLDR rule set name
+// (spellout-ordinal-masculine-genitive => {Kind: ordinal, Gender: masculine, Case: genitive}),
+// or used to request a rule set matching the features of a noun.
+type Features struct {
+	Kind    Kind
+	Gender  string
+	Case    string
+	Number  string
+	Animacy string
+	// Other holds remaining parts of the rule set name, such as numbering, verbose or native
+	Other []string
+}
+
+func (f Features) String() string {
+	res := []string{string(f.Kind)}
+	for _, v := range []string{f.Gender, f.Case, f.Number, f.Animacy} {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+	res = append(res, f.Other...)
+	return strings.Join(res, "-")
+}
+
+var featureGenders = map[string]string{
+	"masculine": "masculine",
+	"feminine":  "feminine",
+	"neuter":    "neuter",
+	"common":    "common",
+	"reale":     "common", // Swedish name for common gender
+}
+
+var featureCases = map[string]bool{
+	"nominative":    true,
+	"genitive":      true,
+	"dative":        true,
+	"accusative":    true,
+	"instrumental":  true,
+	"locative":      true,
+	"prepositional": true,
+	"ablative":      true,
+	"vocative":      true,
+	"oblique":       true,
+	"partitive":     true,
+	"essive":        true,
+	"translative":   true,
+	"inessive":      true,
+	"elative":       true,
+	"illative":      true,
+	"adessive":      true,
+	"allative":      true,
+	"abessive":      true,
+	"comitative":    true,
+	"instructive":   true,
+}
+
+var featureNumbers = map[string]bool{
+	"singular": true,
+	"plural":   true,
+	"dual":     true,
+}
+
+var featureAnimacies = map[string]bool{
+	"animate":   true,
+	"inanimate": true,
+	"personal":  true,
+}
+
+// ParseFeatures parses a CLDR rule set name into a feature bundle. Names not starting with spellout-
+// or digits-, or without a known kind, are not parsed.
+func ParseFeatures(ruleSetName string) (Features, bool) {
+	res := Features{}
+	var parts []string
+	if strings.HasPrefix(ruleSetName, "spellout-") {
+		parts = strings.Split(strings.TrimPrefix(ruleSetName, "spellout-"), "-")
+		switch parts[0] {
+		case "cardinal":
+			res.Kind = Cardinal
+		case "ordinal":
+			res.Kind = Ordinal
+		case "numbering":
+			res.Kind = Cardinal
+			if len(parts) > 1 && parts[1] == "year" {
+				res.Kind = Year
+				parts = parts[1:]
+			} else {
+				res.Other = append(res.Other, "numbering")
+			}
+		default:
+			return res, false
+		}
+	} else if strings.HasPrefix(ruleSetName, "digits-ordinal") {
+		res.Kind = DigitsOrdinal
+		parts = strings.Split(strings.TrimPrefix(ruleSetName, "digits-"), "-")
+	} else {
+		return res, false
+	}
+	for _, p := range parts[1:] {
+		if g, ok := featureGenders[p]; ok && res.Gender == "" {
+			res.Gender = g
+		} else if featureCases[p] && res.Case == "" {
+			res.Case = p
+		} else if featureNumbers[p] && res.Number == "" {
+			res.Number = p
+		} else if featureAnimacies[p] && res.Animacy == "" {
+			res.Animacy = p
+		} else {
+			res.Other = append(res.Other, p)
+		}
+	}
+	return res, true
+}
+
+// Features returns the parsed feature bundles of the public rule sets in the group
+func (g RuleSetGroup) Features() map[string]Features {
+	res := make(map[string]Features)
+	for name, rs := range g.RuleSets {
+		if rs.Private {
+			continue
+		}
+		if f, ok := ParseFeatures(name); ok {
+			res[name] = f
+		}
+	}
+	return res
+}
+
+// featureScore scores how well the features of a rule set match the requested features. Rule sets specifying a
+// feature value other than the requested one are rejected. Each matching feature adds to the score (gender
+// weighs more than case, case more than number and number more than animacy), each feature the request
+// didn't ask for subtracts one from it. A rule set without a value for a requested feature (spellout-ordinal
+// for a request with case dative) is accepted as a fallback.
+func featureScore(req Features, f Features) (int, bool) {
+	if f.Kind != req.Kind {
+		return 0, false
+	}
+	score := 0
+	for _, p := range []struct {
+		req, v string
+		weight int
+	}{
+		{req.Gender, f.Gender, 8},
+		{req.Case, f.Case, 6},
+		{req.Number, f.Number, 4},
+		{req.Animacy, f.Animacy, 2},
+	} {
+		switch {
+		case p.v == "":
+		case p.req == "":
+			score--
+		case p.req == p.v:
+			score += p.weight
+		default:
+			return 0, false
+		}
+	}
+	requested := make(map[string]bool)
+	for _, o := range req.Other {
+		requested[o] = true
+	}
+	for _, o := range f.Other {
+		if requested[o] {
+			score += 2
+		} else {
+			score--
+		}
+	}
+	return score, true
+}
+
+// relax returns the request with the least important feature removed (animacy, number, case, gender, in that order)
+func (f Features) relax() (Features, bool) {
+	switch {
+	case f.Animacy != "":
+		f.Animacy = ""
+	case f.Number != "":
+		f.Number = ""
+	case f.Case != "":
+		f.Case = ""
+	case f.Gender != "":
+		f.Gender = ""
+	default:
+		return f, false
+	}
+	return f, true
+}
+
+// normalize returns the features with gender aliases replaced by the names used in parsed features (reale => common)
+func (f Features) normalize() Features {
+	if g, ok := featureGenders[f.Gender]; ok {
+		f.Gender = g
+	}
+	return f
+}
+
+// selectRuleSet returns the best matching rule set name among the candidates. Ties are resolved
+// by preferring the built-in rule set for the kind (see DefaultRuleSetName), and then by name.
+func selectRuleSet(lang Language, candidates map[string]Features, req Features) (string, error) {
+	req = req.normalize()
+	defaultName, _ := DefaultRuleSetName(lang, req.Kind)
+	for r, ok := req, true; ok; r, ok = r.relax() {
+		var best []string
+		bestScore := 0
+		for name, f := range candidates {
+			score, ok := featureScore(r, f)
+			if !ok {
+				continue
+			}
+			if len(best) == 0 || score > bestScore {
+				best, bestScore = []string{name}, score
+			} else if score == bestScore {
+				best = append(best, name)
+			}
+		}
+		if len(best) > 0 {
+			sort.Slice(best, func(i, j int) bool {
+				if (best[i] == defaultName) != (best[j] == defaultName) {
+					return best[i] == defaultName
+				}
+				return best[i] < best[j]
+			})
+			return best[0], nil
+		}
+	}
+	return "", fmt.Errorf("no rule set matching %s", req)
+}
+
+// SelectRuleSet returns the name of the public rule set in the group that best matches the requested
+// features. If no rule set is compatible with the request, features are dropped in the order animacy,
+// number, case and gender, until a rule set is found.
+func (g *RuleSetGroup) SelectRuleSet(req Features) (string, error) {
+	return selectRuleSet(g.Language, g.Features(), req)
+}
+
+// SpelloutFeatures spells out the input string using the rule set selected by SelectRuleSet
+func (g *RuleSetGroup) SpelloutFeatures(input string, req Features, debug bool) (string, error) {
+	name, err := g.SelectRuleSet(req)
+	if err != nil {
+		return "", err
+	}
+	return g.Spellout(input, name, debug)
+}
+
+// SelectRuleSet returns the rule set group and the public rule set that best match the requested
+// features, selected from all groups in the package (see RuleSetGroup.SelectRuleSet)
+func (r *RulePackage) SelectRuleSet(req Features) (string, string, error) {
+	candidates := make(map[string]Features)
+	groups := make(map[string]string)
+	for _, g := range r.RuleSetGroups {
+		for name, f := range g.Features() {
+			if _, ok := groups[name]; !ok {
+				candidates[name] = f
+				groups[name] = g.Name
+			}
+		}
+	}
+	name, err := selectRuleSet(r.Language, candidates, req)
+	if err != nil {
+		return "", "", err
+	}
+	return groups[name], name, nil
+}
+
+// SpelloutFeatures spells out the input string using the rule set selected by SelectRuleSet
+func (r *RulePackage) SpelloutFeatures(input string, req Features, debug bool) (string, error) {
+	groupName, ruleSetName, err := r.SelectRuleSet(req)
+	if err != nil {
+		return "", err
+	}
+	return r.Spellout(input, groupName, ruleSetName, debug)
+}
@@ -0,0 +1,105 @@
+package rbnf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFeatures(t *testing.T) {
+	tests := []struct {
+		name string
+		exp  Features
+	}{
+		{"spellout-cardinal-feminine", Features{Kind: Cardinal, Gender: "feminine"}},
+		{"spellout-ordinal-masculine-genitive", Features{Kind: Ordinal, Gender: "masculine", Case: "genitive"}},
+		{"spellout-cardinal-neuter-plural", Features{Kind: Cardinal, Gender: "neuter", Number: "plural"}},
+		{"spellout-cardinal-reale", Features{Kind: Cardinal, Gender: "common"}},
+		{"spellout-cardinal-masculine-animate", Features{Kind: Cardinal, Gender: "masculine", Animacy: "animate"}},
+		{"spellout-numbering", Features{Kind: Cardinal, Other: []string{"numbering"}}},
+		{"spellout-numbering-year", Features{Kind: Year}},
+		{"spellout-ordinal-masculine-adjective", Features{Kind: Ordinal, Gender: "masculine", Other: []string{"adjective"}}},
+		{"digits-ordinal-feminine-plural", Features{Kind: DigitsOrdinal, Gender: "feminine", Number: "plural"}},
+	}
+	for _, test := range tests {
+		res, ok := ParseFeatures(test.name)
+		if !ok {
+			t.Errorf("couldn't parse %s", test.name)
+		} else if !reflect.DeepEqual(res, test.exp) {
+			t.Errorf(fs, test.exp, res)
+		}
+	}
+
+	for _, name := range []string{"lenient-parse", "spellout-hundreds", "roman-upper"} {
+		if res, ok := ParseFeatures(name); ok {
+			t.Errorf("expected failure for %s, got %v", name, res)
+		}
+	}
+}
+
+func TestSelectRuleSet(t *testing.T) {
+	lang := Language("xx")
+	ruleSets := []RuleSet{}
+	for _, name := range []string{
+		"spellout-numbering",
+		"spellout-numbering-year",
+		"spellout-cardinal",
+		"spellout-cardinal-feminine",
+		"spellout-cardinal-neuter",
+		"spellout-cardinal-reale",
+		"spellout-ordinal-masculine",
+		"spellout-ordinal-feminine",
+		"spellout-ordinal-feminine-dative",
+		"spellout-ordinal-feminine-plural",
+		"spellout-ordinal-feminine-genitive-plural",
+	} {
+		ruleSets = append(ruleSets, RuleSet{Name: name, Rules: []BaseRule{NewIntRule(lang, 0, 10, name)}})
+	}
+	ruleSets = append(ruleSets, RuleSet{Name: "spellout-cardinal-masculine", Private: true, Rules: []BaseRule{NewIntRule(lang, 0, 10, "private")}})
+	g, err := NewRuleSetGroup("SpelloutRules", lang, ruleSets)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		req Features
+		exp string
+	}{
+		{Features{Kind: Cardinal}, "spellout-cardinal"},
+		{Features{Kind: Cardinal, Gender: "feminine"}, "spellout-cardinal-feminine"},
+		// gender aliases are normalized in requests too
+		{Features{Kind: Cardinal, Gender: "reale"}, "spellout-cardinal-reale"},
+		{Features{Kind: Cardinal, Gender: "common"}, "spellout-cardinal-reale"},
+		// private rule sets are never selected
+		{Features{Kind: Cardinal, Gender: "masculine"}, "spellout-cardinal"},
+		{Features{Kind: Cardinal, Other: []string{"numbering"}}, "spellout-numbering"},
+		{Features{Kind: Year}, "spellout-numbering-year"},
+		{Features{Kind: Ordinal, Gender: "feminine", Case: "dative"}, "spellout-ordinal-feminine-dative"},
+		{Features{Kind: Ordinal, Gender: "feminine", Case: "accusative"}, "spellout-ordinal-feminine"},
+		{Features{Kind: Ordinal, Gender: "feminine", Case: "genitive", Number: "plural"}, "spellout-ordinal-feminine-genitive-plural"},
+		// case weighs more than number
+		{Features{Kind: Ordinal, Gender: "feminine", Case: "dative", Number: "plural"}, "spellout-ordinal-feminine-dative"},
+		{Features{Kind: Ordinal, Gender: "feminine", Case: "accusative", Number: "plural"}, "spellout-ordinal-feminine-plural"},
+		{Features{Kind: Ordinal, Gender: "masculine", Case: "dative"}, "spellout-ordinal-masculine"},
+		// no neuter ordinal: gender is relaxed, and ties resolved by name
+		{Features{Kind: Ordinal, Gender: "neuter"}, "spellout-ordinal-feminine"},
+	}
+	for _, test := range tests {
+		res, err := g.SelectRuleSet(test.req)
+		if err != nil {
+			t.Error(err)
+		} else if res != test.exp {
+			t.Errorf("%v: "+fs, test.req, test.exp, res)
+		}
+	}
+
+	if _, err := g.SelectRuleSet(Features{Kind: DigitsOrdinal}); err == nil {
+		t.Error("expected error here")
+	}
+
+	res, err := g.SpelloutFeatures("1", Features{Kind: Ordinal, Gender: "feminine", Case: "dative"}, false)
+	if exp := "spellout-ordinal-feminine-dative"; err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+}
@@ -0,0 +1,176 @@
+package rbnf
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Number is a number bound to a rule package, to be spelled out using fmt or golang.org/x/text/message.
+// It implements fmt.Formatter and fmt.Stringer. Since message.Printer handles fmt.Formatter values, a
+// Number is spelled out by message.Printer as well.
+//
+// Supported verbs are %v and %s (spellout) and %q (quoted spellout). The + flag capitalizes the first
+// letter (%+v), the precision sets the number of fraction digits (%.2v, see ApplyPrecision), and width
+// and the - flag pad the output as for strings. %#v prints the Go syntax representation of the number.
+// Failures are reported using the fmt convention, for example %!v(rbnf.Number=1066: no such rule set: x).
+type Number struct {
+	pkg     *RulePackage
+	value   interface{}
+	kind    Kind
+	group   string
+	ruleSet string
+}
+
+// Number returns n as a Number to be spelled out using the package's cardinal rule set, unless another
+// numeral kind or rule set is specified. n can be any Go integer or float type, *big.Int, *big.Rat,
+// *big.Float, or a string in the canonical rule input format (see ApplyPrecision).
+func (r *RulePackage) Number(n interface{}) Number {
+	return Number{pkg: r, value: n, kind: Cardinal}
+}
+
+// Kind returns a copy of the number, spelled out using the rule set for the numeral kind
+func (n Number) Kind(kind Kind) Number {
+	n.kind = kind
+	n.ruleSet = ""
+	return n
+}
+
+// RuleSet returns a copy of the number, spelled out using the named rule set
+func (n Number) RuleSet(ruleSetName string) Number {
+	n.ruleSet = ruleSetName
+	return n
+}
+
+// Group returns a copy of the number, spelled out using the named rule set group (default: the first group with the rule set)
+func (n Number) Group(groupName string) Number {
+	n.group = groupName
+	return n
+}
+
+// input returns the number in the canonical rule input format
+func (n Number) input() (string, error) {
+	switch v := n.value.(type) {
+	case int:
+		return canonicalInt64(int64(v)), nil
+	case int8:
+		return canonicalInt64(int64(v)), nil
+	case int16:
+		return canonicalInt64(int64(v)), nil
+	case int32:
+		return canonicalInt64(int64(v)), nil
+	case int64:
+		return canonicalInt64(v), nil
+	case uint:
+		return canonicalUint64(uint64(v)), nil
+	case uint8:
+		return canonicalUint64(uint64(v)), nil
+	case uint16:
+		return canonicalUint64(uint64(v)), nil
+	case uint32:
+		return canonicalUint64(uint64(v)), nil
+	case uint64:
+		return canonicalUint64(v), nil
+	case float32:
+		return canonicalFloat(float64(v), -1)
+	case float64:
+		return canonicalFloat(v, -1)
+	case *big.Int, *big.Rat, *big.Float:
+		return canonicalBig(v, -1)
+	case string:
+		return ApplyPrecision(v)
+	default:
+		return "", fmt.Errorf("unsupported type for spellout: %T", n.value)
+	}
+}
+
+// spellout spells out the number. Negative precision means no precision is applied.
+func (n Number) spellout(precision int) (string, error) {
+	if n.pkg == nil {
+		return "", fmt.Errorf("number has no rule package")
+	}
+	input, err := n.input()
+	if err != nil {
+		return "", err
+	}
+	if precision >= 0 {
+		input, err = ApplyPrecision(input, MinFractionDigits(precision), MaxFractionDigits(precision))
+		if err != nil {
+			return "", err
+		}
+	}
+	groupName, ruleSetName := n.group, n.ruleSet
+	if ruleSetName == "" {
+		groupName, ruleSetName, err = n.pkg.RuleSetFor(n.kind)
+		if err != nil {
+			return "", err
+		}
+	}
+	if groupName == "" {
+		var ok bool
+		groupName, ok = n.pkg.findGroupWithRuleSet(ruleSetName)
+		if !ok {
+			return "", fmt.Errorf("no such rule set: %s", ruleSetName)
+		}
+	}
+	return n.pkg.Spellout(input, groupName, ruleSetName, n.pkg.Debug)
+}
+
+// Spellout spells out the number
+func (n Number) Spellout() (string, error) {
+	return n.spellout(-1)
+}
+
+// String returns the spelled out number, or a %!v(...) error string if it couldn't be spelled out
+func (n Number) String() string {
+	return fmt.Sprint(n)
+}
+
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToTitle(r)) + s[size:]
+}
+
+// Format implements fmt.Formatter
+func (n Number) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v', 's', 'q':
+	default:
+		fmt.Fprintf(s, "%%!%c(rbnf.Number=%v)", verb, n.value)
+		return
+	}
+	if verb == 'v' && s.Flag('#') {
+		fmt.Fprintf(s, "rbnf.Number(%#v)", n.value)
+		return
+	}
+	precision, ok := s.Precision()
+	if !ok {
+		precision = -1
+	}
+	res, err := n.spellout(precision)
+	if err != nil {
+		fmt.Fprintf(s, "%%!%c(rbnf.Number=%v: %v)", verb, n.value, err)
+		return
+	}
+	if s.Flag('+') {
+		res = capitalize(res)
+	}
+	if verb == 'q' {
+		res = fmt.Sprintf("%q", res)
+	}
+	if width, ok := s.Width(); ok {
+		if pad := width - utf8.RuneCountInString(res); pad > 0 {
+			if s.Flag('-') {
+				res = res + strings.Repeat(" ", pad)
+			} else {
+				res = strings.Repeat(" ", pad) + res
+			}
+		}
+	}
+	fmt.Fprint(s, res)
+}
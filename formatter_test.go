@@ -0,0 +1,78 @@
+package rbnf_test
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stts-se/rbnf"
+	"github.com/stts-se/rbnf/xmlreader"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+var fs = "Expected '%v', got '%v'"
+
+// testPackage reads the rule package of a CLDR file in xmlreader/test_data
+func testPackage(t *testing.T, lang string) rbnf.RulePackage {
+	pkg, err := xmlreader.RulesFromXMLFile("xmlreader/test_data/" + lang + ".xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+func TestNumberFormat(t *testing.T) {
+	pkg := testPackage(t, "en")
+
+	tests := []struct {
+		format string
+		arg    interface{}
+		exp    string
+	}{
+		{"%v", pkg.Number(3), "three"},
+		{"%s", pkg.Number(uint8(2)), "two"},
+		{"%+v", pkg.Number(3), "Three"},
+		{"%q", pkg.Number(1), `"one"`},
+		{"%v", pkg.Number(-2), "minus two"},
+		{"%v", pkg.Number(2.5).RuleSet("spellout-cardinal"), "two point five"},
+		{"%.1v", pkg.Number(2.45).RuleSet("spellout-cardinal"), "two point four"},
+		{"%.0v", pkg.Number(2.4).RuleSet("spellout-cardinal"), "two"},
+		{"%v", pkg.Number("1.50").RuleSet("spellout-cardinal"), "one point five"},
+		{"%v", pkg.Number(big.NewInt(4)), "four"},
+		{"[%6v]", pkg.Number(2), "[   two]"},
+		{"[%-6v]", pkg.Number(2), "[two   ]"},
+		{"%v", pkg.Number(2).RuleSet("spellout-ordinal"), "second"},
+		{"%+v", pkg.Number(3).Kind(rbnf.Ordinal), "Third"},
+		{"%v", pkg.Number(3).Kind(rbnf.Ordinal).Group("SpelloutRules"), "third"},
+		{"%#v", pkg.Number(3), "rbnf.Number(3)"},
+		{"%d", pkg.Number(3), "%!d(rbnf.Number=3)"},
+		{"%v", pkg.Number(3).RuleSet("spellout-cardinal-feminine"), "%!v(rbnf.Number=3: no such rule set: spellout-cardinal-feminine)"},
+		{"%v", pkg.Number(3).Group("NoSuchGroup").RuleSet("spellout-ordinal"), "%!v(rbnf.Number=3: no such rule set group: NoSuchGroup)"},
+		{"%v", pkg.Number(true), "%!v(rbnf.Number=true: unsupported type for spellout: bool)"},
+	}
+	for _, test := range tests {
+		if res := fmt.Sprintf(test.format, test.arg); res != test.exp {
+			t.Errorf(fs, test.exp, res)
+		}
+	}
+
+	if exp, res := "second", pkg.Number(2).RuleSet("spellout-ordinal").String(); res != exp {
+		t.Errorf(fs, exp, res)
+	}
+	if res := pkg.Number("x").Kind(rbnf.Ordinal).String(); !strings.HasPrefix(res, "%!v(rbnf.Number=x: ") {
+		t.Errorf("expected error string, got %s", res)
+	}
+	if _, err := pkg.Number("x").Kind(rbnf.Ordinal).Spellout(); err == nil {
+		t.Error("expected error here")
+	}
+}
+
+func TestNumberMessagePrinter(t *testing.T) {
+	pkg := testPackage(t, "en")
+	p := message.NewPrinter(language.English)
+	if exp, res := "Two apples, the first one is red", p.Sprintf("%+v apples, the %v one is red", pkg.Number(2), pkg.Number(1).Kind(rbnf.Ordinal)); res != exp {
+		t.Errorf(fs, exp, res)
+	}
+}
@@ -0,0 +1,600 @@
+package rbnf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FSTEpsilon is the epsilon symbol of FST symbol tables
+const FSTEpsilon = "<eps>"
+
+// FSTSpace is the output symbol for a space between words
+const FSTSpace = "<space>"
+
+// DefaultFSTEnumerationLimit is the default maximum number of values enumerated for rules that can't be compiled per digit group
+const DefaultFSTEnumerationLimit = 10000
+
+// FSTArc is an arc of an FST. Empty input or output labels are epsilon.
+type FSTArc struct {
+	From, To int
+	In, Out  string
+	Weight   float64
+}
+
+// FST is a finite-state transducer from the digits of a number to its spellout, compiled from a rule set
+// (see RuleSetGroup.FST). The input symbols are the digits 0-9, and the output symbols are the text
+// fragments of the rules, split at spaces, with FSTSpace for the spaces. The output of a path is the
+// concatenation of its output symbols. Weights are in the tropical semiring: the weight of a path is the
+// rule cost (see FSTRuleCost) times the number of rules applied, and zero by default.
+//
+// The FST is not optimized: it has epsilon arcs, and is not determinized or minimized. This is best done
+// by OpenFst (fstrmepsilon, fstdeterminize, fstminimize) after compiling the AT&T text format.
+type FST struct {
+	Start     int
+	NumStates int
+	Arcs      []FSTArc
+	Finals    []int
+
+	group   *RuleSetGroup
+	ruleSet RuleSet
+	lo, hi  int
+	// arcs by source state and final states, for Apply
+	indexOnce sync.Once
+	index     map[int][]FSTArc
+	final     map[int]bool
+}
+
+// InputSymbols returns the input symbol table: the symbol with id i is at index i
+func (f *FST) InputSymbols() []string {
+	return []string{FSTEpsilon, "0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}
+}
+
+// OutputSymbols returns the output symbol table: the symbol with id i is at index i
+func (f *FST) OutputSymbols() []string {
+	seen := make(map[string]bool)
+	for _, a := range f.Arcs {
+		if a.Out != "" && a.Out != FSTSpace {
+			seen[a.Out] = true
+		}
+	}
+	return append([]string{FSTEpsilon, FSTSpace}, sortedKeys(seen)...)
+}
+
+func fstSymbol(s string) string {
+	if s == "" {
+		return FSTEpsilon
+	}
+	return s
+}
+
+// WriteATT writes the FST in AT&T text format, with symbols (fstcompile --isymbols=... --osymbols=...).
+// Weights are written for arcs with non-zero weight.
+func (f *FST) WriteATT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	arcs := append([]FSTArc{}, f.Arcs...)
+	// the first line must be an arc from the start state
+	sort.SliceStable(arcs, func(i, j int) bool { return arcs[i].From == f.Start && arcs[j].From != f.Start })
+	for _, a := range arcs {
+		if a.Weight != 0 {
+			fmt.Fprintf(bw, "%d\t%d\t%s\t%s\t%g\n", a.From, a.To, fstSymbol(a.In), fstSymbol(a.Out), a.Weight)
+			continue
+		}
+		fmt.Fprintf(bw, "%d\t%d\t%s\t%s\n", a.From, a.To, fstSymbol(a.In), fstSymbol(a.Out))
+	}
+	for _, s := range f.Finals {
+		fmt.Fprintf(bw, "%d\n", s)
+	}
+	return bw.Flush()
+}
+
+// WriteSymbols writes a symbol table in OpenFst text format
+func WriteSymbols(w io.Writer, symbols []string) error {
+	bw := bufio.NewWriter(w)
+	for i, s := range symbols {
+		fmt.Fprintf(bw, "%s\t%d\n", s, i)
+	}
+	return bw.Flush()
+}
+
+// Apply returns the output of the FST for the digits of the input, or an error if the input is not accepted.
+// Consecutive spaces are collapsed, as in spellout. Apply is safe for concurrent use, but the arcs and final
+// states must not be modified after the first call.
+func (f *FST) Apply(input string) (string, error) {
+	res, _, err := f.apply(input)
+	return res, err
+}
+
+// apply returns the output and the weight of the first path accepting the input
+func (f *FST) apply(input string) (string, float64, error) {
+	f.indexOnce.Do(func() {
+		f.index = make(map[int][]FSTArc)
+		for _, a := range f.Arcs {
+			f.index[a.From] = append(f.index[a.From], a)
+		}
+		f.final = make(map[int]bool)
+		for _, s := range f.Finals {
+			f.final[s] = true
+		}
+	})
+	arcs, final := f.index, f.final
+	var out []string
+	var weight float64
+	var search func(state, pos int) bool
+	search = func(state, pos int) bool {
+		if pos == len(input) && final[state] {
+			return true
+		}
+		for _, a := range arcs[state] {
+			next := pos
+			if a.In != "" {
+				if pos >= len(input) || input[pos:pos+1] != a.In {
+					continue
+				}
+				next++
+			}
+			out = append(out, a.Out)
+			weight += a.Weight
+			if search(a.To, next) {
+				return true
+			}
+			out = out[:len(out)-1]
+			weight -= a.Weight
+		}
+		return false
+	}
+	if !search(f.Start, 0) {
+		return "", 0, fmt.Errorf("input not accepted by FST: %s", input)
+	}
+	return collapseSpaces(strings.Replace(strings.Join(out, ""), FSTSpace, " ", -1)), weight, nil
+}
+
+// collapseSpaces replaces runs of spaces with a single space
+func collapseSpaces(s string) string {
+	for strings.Contains(s, "  ") {
+		s = strings.Replace(s, "  ", " ", -1)
+	}
+	return s
+}
+
+// Check compares the FST output with RuleSetGroup.Spellout for n numbers sampled from the range of the FST
+// (always including the range limits), and returns an error for the first difference. Consecutive spaces
+// are collapsed in the spellouts, as in Apply.
+func (f *FST) Check(n int, rnd *rand.Rand) error {
+	samples := []int{f.lo, f.hi}
+	for i := 0; i < n; i++ {
+		samples = append(samples, f.lo+rnd.Intn(f.hi-f.lo+1))
+	}
+	for _, v := range samples {
+		input := strconv.Itoa(v)
+		exp, err := f.group.Spellout(input, f.ruleSet.Name, false)
+		if err != nil {
+			if _, ferr := f.Apply(input); ferr == nil {
+				return fmt.Errorf("FST accepts %s, but spellout fails : %v", input, err)
+			}
+			continue
+		}
+		res, err := f.Apply(input)
+		if err != nil {
+			return err
+		}
+		if exp = collapseSpaces(exp); res != exp {
+			return fmt.Errorf("FST output for %s differs from spellout: %s / %s", input, res, exp)
+		}
+	}
+	return nil
+}
+
+// fstPath is a part of an FST under construction, from start to end
+type fstPath struct {
+	start, end int
+}
+
+type fstBuilder struct {
+	g         *RuleSetGroup
+	fst       *FST
+	enumLimit int
+	ruleCost  float64
+	active    map[string]bool
+}
+
+// FSTOption is an option for RuleSetGroup.FST
+type FSTOption func(*fstBuilder)
+
+// FSTRuleCost sets the weight added to a path for each rule applied (default 0). Numbers that are enumerated
+// count as one rule.
+func FSTRuleCost(cost float64) FSTOption {
+	return func(b *fstBuilder) { b.ruleCost = cost }
+}
+
+// errFSTUnsupported is returned by rule compilation for rules that must be enumerated
+var errFSTUnsupported = fmt.Errorf("rule can't be compiled per digit group")
+
+func (b *fstBuilder) state() int {
+	b.fst.NumStates++
+	return b.fst.NumStates - 1
+}
+
+func (b *fstBuilder) arc(from, to int, in, out string) {
+	b.fst.Arcs = append(b.fst.Arcs, FSTArc{From: from, To: to, In: in, Out: out})
+}
+
+// weighted returns the paths with the rule cost added, on an epsilon arc before each path
+func (b *fstBuilder) weighted(paths []fstPath) []fstPath {
+	if b.ruleCost == 0 {
+		return paths
+	}
+	res := make([]fstPath, len(paths))
+	for i, p := range paths {
+		s := b.state()
+		b.fst.Arcs = append(b.fst.Arcs, FSTArc{From: s, To: p.start, Weight: b.ruleCost})
+		res[i] = fstPath{s, p.end}
+	}
+	return res
+}
+
+func (b *fstBuilder) epsilon() fstPath {
+	s := b.state()
+	return fstPath{s, s}
+}
+
+func (b *fstBuilder) concat(x, y fstPath) fstPath {
+	b.arc(x.end, y.start, "", "")
+	return fstPath{x.start, y.end}
+}
+
+func (b *fstBuilder) union(paths []fstPath) (fstPath, bool) {
+	if len(paths) == 0 {
+		return fstPath{}, false
+	}
+	if len(paths) == 1 {
+		return paths[0], true
+	}
+	res := fstPath{b.state(), b.state()}
+	for _, p := range paths {
+		b.arc(res.start, p.start, "", "")
+		b.arc(p.end, res.end, "", "")
+	}
+	return res, true
+}
+
+// text returns a path with epsilon input, outputting the text
+func (b *fstBuilder) text(s string) fstPath {
+	res := b.epsilon()
+	for i, part := range strings.Split(s, " ") {
+		if i > 0 {
+			next := b.state()
+			b.arc(res.end, next, "", FSTSpace)
+			res.end = next
+		}
+		if part != "" {
+			next := b.state()
+			b.arc(res.end, next, "", part)
+			res.end = next
+		}
+	}
+	return res
+}
+
+// digits returns a path with epsilon output, accepting the digits of s
+func (b *fstBuilder) digits(s string) fstPath {
+	res := b.epsilon()
+	for _, d := range s {
+		next := b.state()
+		b.arc(res.end, next, string(d), "")
+		res.end = next
+	}
+	return res
+}
+
+// acceptFixed returns a path with epsilon output, accepting the numbers lo..hi written with exactly w digits
+func (b *fstBuilder) acceptFixed(lo, hi, w int) (fstPath, bool) {
+	if lo > hi {
+		return fstPath{}, false
+	}
+	if w == 0 {
+		return b.epsilon(), lo <= 0 && 0 <= hi
+	}
+	p := exp(10, w-1)
+	var paths []fstPath
+	for d := lo / p; d <= hi/p && d <= 9; d++ {
+		l, h := lo-d*p, hi-d*p
+		if l < 0 {
+			l = 0
+		}
+		if h > p-1 {
+			h = p - 1
+		}
+		rest, ok := b.acceptFixed(l, h, w-1)
+		if !ok {
+			continue
+		}
+		first := b.state()
+		b.arc(first, rest.start, strconv.Itoa(d), "")
+		paths = append(paths, fstPath{first, rest.end})
+	}
+	return b.union(paths)
+}
+
+// acceptNatural returns a path with epsilon output, accepting the numbers lo..hi written without leading zeros
+func (b *fstBuilder) acceptNatural(lo, hi int) (fstPath, bool) {
+	var paths []fstPath
+	for w := 1; w <= len(strconv.Itoa(hi)); w++ {
+		l, h := naturalRange(w, lo, hi)
+		if p, ok := b.acceptFixed(l, h, w); ok && l <= h {
+			paths = append(paths, p)
+		}
+	}
+	return b.union(paths)
+}
+
+// naturalRange returns the part of lo..hi written with w digits without leading zeros
+func naturalRange(w, lo, hi int) (int, int) {
+	min, max := exp(10, w-1), exp(10, w)-1
+	if w == 1 {
+		min = 0
+	}
+	if lo > min {
+		min = lo
+	}
+	if hi < max {
+		max = hi
+	}
+	return min, max
+}
+
+// fixed returns a path for the rule set, accepting the numbers lo..hi written with exactly w digits (with leading zeros)
+func (b *fstBuilder) fixed(rs RuleSet, lo, hi, w int) (fstPath, bool, error) {
+	var paths []fstPath
+	for n := 1; n <= w; n++ {
+		l, h := naturalRange(n, lo, hi)
+		if l > h {
+			continue
+		}
+		p, ok, err := b.natural(rs, l, h)
+		if err != nil {
+			return fstPath{}, false, err
+		}
+		if !ok {
+			continue
+		}
+		zeros := b.digits(strings.Repeat("0", w-n))
+		paths = append(paths, b.concat(zeros, p))
+	}
+	res, ok := b.union(paths)
+	return res, ok, nil
+}
+
+// natural returns a path for the rule set, accepting the numbers lo..hi written without leading zeros
+func (b *fstBuilder) natural(rs RuleSet, lo, hi int) (fstPath, bool, error) {
+	key := fmt.Sprintf("%s %d %d", rs.Name, lo, hi)
+	if b.active[key] {
+		return fstPath{}, false, fmt.Errorf("recursive rule set %s for %d..%d", rs.Name, lo, hi)
+	}
+	b.active[key] = true
+	defer delete(b.active, key)
+
+	ints := []int{}
+	for i, r := range rs.Rules {
+		if r.Base.IsInt() {
+			ints = append(ints, i)
+		}
+	}
+	var paths []fstPath
+	for j, i := range ints {
+		r := rs.Rules[i]
+		next := maxInt
+		if j+1 < len(ints) {
+			next = rs.Rules[ints[j+1]].Base.Int - 1
+		}
+		l, h := lo, hi
+		if r.Base.Int > l {
+			l = r.Base.Int
+		}
+		if next < h {
+			h = next
+		}
+		if l > h {
+			continue
+		}
+		ps, err := b.rule(rs, r, l, h)
+		if err == errFSTUnsupported {
+			ps, err = b.enumerate(rs, l, h)
+		}
+		if err != nil {
+			return fstPath{}, false, err
+		}
+		paths = append(paths, b.weighted(ps)...)
+	}
+	res, ok := b.union(paths)
+	return res, ok, nil
+}
+
+// enumerate returns a path for each number lo..hi, with the output of spellout
+func (b *fstBuilder) enumerate(rs RuleSet, lo, hi int) ([]fstPath, error) {
+	if hi-lo >= b.enumLimit {
+		return nil, fmt.Errorf("too many numbers to enumerate for rule set %s: %d..%d", rs.Name, lo, hi)
+	}
+	var res []fstPath
+	for v := lo; v <= hi; v++ {
+		input := strconv.Itoa(v)
+		out, err := b.g.spellout(input, rs, false)
+		if err != nil {
+			continue
+		}
+		res = append(res, b.concat(b.digits(input), b.text(out)))
+	}
+	return res, nil
+}
+
+// rule returns the paths of an integer rule for the numbers lo..hi. The input digits are split into the
+// digits of the quotient and the remainder of the rule's divisor, which must be a power of ten (as for radix 10 or 100).
+func (b *fstBuilder) rule(rs RuleSet, r BaseRule, lo, hi int) ([]fstPath, error) {
+	d := r.Base.Divisor()
+	k := len(strconv.Itoa(d)) - 1
+	if d != exp(10, k) {
+		return nil, errFSTUnsupported
+	}
+	for _, sub := range r.Subs {
+		if sub.IsNumericFormatter() || sub.IsPluralFormatter() || sub.IsError() {
+			return nil, errFSTUnsupported
+		}
+	}
+
+	// split lo..hi into parts with a range of quotients and a range of remainders
+	type part struct{ qlo, qhi, rlo, rhi int }
+	var parts []part
+	qlo, qhi := lo/d, hi/d
+	if qlo == qhi {
+		parts = append(parts, part{qlo, qlo, lo % d, hi % d})
+	} else {
+		parts = append(parts, part{qlo, qlo, lo % d, d - 1})
+		if qhi-qlo > 1 {
+			parts = append(parts, part{qlo + 1, qhi - 1, 0, d - 1})
+		}
+		parts = append(parts, part{qhi, qhi, 0, hi % d})
+	}
+
+	var res []fstPath
+	for _, p := range parts {
+		// the optional subs are omitted if the remainder is zero (always, if the divisor is 1)
+		if p.rlo == 0 {
+			path, ok, err := b.ruleSubs(rs, r, true, p.qlo, p.qhi, 0, 0, k)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				res = append(res, path)
+			}
+		}
+		rlo := p.rlo
+		if rlo == 0 {
+			rlo = 1
+		}
+		if d > 1 && rlo <= p.rhi {
+			path, ok, err := b.ruleSubs(rs, r, false, p.qlo, p.qhi, rlo, p.rhi, k)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				res = append(res, path)
+			}
+		}
+	}
+	return res, nil
+}
+
+// ruleSubs returns the path of the subs of an integer rule, for the quotients qlo..qhi and remainders rlo..rhi (written with k digits)
+func (b *fstBuilder) ruleSubs(rs RuleSet, r BaseRule, omitOptional bool, qlo, qhi, rlo, rhi, k int) (fstPath, bool, error) {
+	d := exp(10, k)
+	res := b.epsilon()
+	quotient, remainder := false, false
+	for _, sub := range r.Subs {
+		if sub.Optional && omitOptional {
+			continue
+		}
+		ref := rs
+		if sub.IsRuleRef() {
+			var ok bool
+			if ref, ok = b.g.FindRuleSet(sub.RuleRef); !ok {
+				return fstPath{}, false, fmt.Errorf("no such rule set: %s", sub.RuleRef)
+			}
+		}
+		var path fstPath
+		var ok bool
+		var err error
+		switch sub.Operation {
+		case "<<":
+			if quotient || remainder {
+				return fstPath{}, false, errFSTUnsupported
+			}
+			path, ok, err = b.natural(ref, qlo, qhi)
+			quotient = true
+		case ">>":
+			if remainder {
+				return fstPath{}, false, errFSTUnsupported
+			}
+			if !quotient {
+				path, ok = b.acceptNatural(qlo, qhi)
+				if !ok {
+					return fstPath{}, false, nil
+				}
+				res = b.concat(res, path)
+				quotient = true
+			}
+			path, ok, err = b.fixed(ref, rlo, rhi, k)
+			remainder = true
+		case "==":
+			if quotient || remainder || qlo != qhi && (rlo != 0 || rhi != d-1) {
+				return fstPath{}, false, errFSTUnsupported
+			}
+			path, ok, err = b.natural(ref, qlo*d+rlo, qhi*d+rhi)
+			quotient, remainder = true, true
+		default:
+			path, ok = b.text(strings.Trim(sub.Orth, "'")), true
+		}
+		if err != nil {
+			return fstPath{}, false, err
+		}
+		if !ok {
+			return fstPath{}, false, nil
+		}
+		res = b.concat(res, path)
+	}
+	if !quotient {
+		path, ok := b.acceptNatural(qlo, qhi)
+		if !ok {
+			return fstPath{}, false, nil
+		}
+		// the quotient digits come first in the input, and have no output
+		res = b.concat(path, res)
+	}
+	if !remainder {
+		path, ok := b.acceptFixed(rlo, rhi, k)
+		if !ok {
+			return fstPath{}, false, nil
+		}
+		res = b.concat(res, path)
+	}
+	return res, true, nil
+}
+
+// FST compiles a rule set into a transducer from the digits of the integers lo..hi to their spellouts
+// (see FST). Integer rules with decimal divisors are compiled per digit group: the digits of the
+// quotient and the remainder are compiled separately, so that large ranges don't need to be enumerated.
+// Rules that can't be compiled this way, such as rules with numeric or plural formatters, are
+// enumerated using spellout, for at most DefaultFSTEnumerationLimit numbers per rule. Numbers that
+// can't be spelled out have no path in the FST.
+func (g *RuleSetGroup) FST(ruleSetName string, lo, hi int, opts ...FSTOption) (*FST, error) {
+	rs, ok := g.FindRuleSet(ruleSetName)
+	if !ok {
+		return nil, fmt.Errorf("no such rule set: %s", ruleSetName)
+	}
+	if lo < 0 || lo > hi {
+		return nil, fmt.Errorf("invalid range for FST: %d..%d", lo, hi)
+	}
+	b := &fstBuilder{
+		g:         g,
+		fst:       &FST{group: g, ruleSet: rs, lo: lo, hi: hi},
+		enumLimit: DefaultFSTEnumerationLimit,
+		active:    make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	path, ok, err := b.natural(rs, lo, hi)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("no rules in rule set %s for %d..%d", ruleSetName, lo, hi)
+	}
+	b.fst.Start = path.start
+	b.fst.Finals = []int{path.end}
+	return b.fst, nil
+}
@@ -0,0 +1,174 @@
+package rbnf
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFST(t *testing.T) {
+	g := testGroup(t)
+	fst, err := g.FST("spellout-numbering", 0, 399)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"0":   "noll",
+		"3":   "tre",
+		"7":   "7",
+		"20":  "tjugo",
+		"23":  "tjugotre",
+		"100": "etthundra",
+		"101": "etthundra ett",
+		"223": "tvåhundra tjugotre",
+		"399": "trehundra 99",
+	}
+	for input, exp := range tests {
+		res, err := fst.Apply(input)
+		if err != nil {
+			t.Error(err)
+		} else if res != exp {
+			t.Errorf(fs, exp, res)
+		}
+	}
+	for _, input := range []string{"", "007", "400", "1000", "-1"} {
+		if res, err := fst.Apply(input); err == nil {
+			t.Errorf("expected error for input %s, got %s", input, res)
+		}
+	}
+	if err := fst.Check(200, rand.New(rand.NewSource(1))); err != nil {
+		t.Error(err)
+	}
+}
+
+// Run with go test -race to check that Apply can be used concurrently
+func TestFSTConcurrent(t *testing.T) {
+	g := testGroup(t)
+	fst, err := g.FST("spellout-numbering", 0, 399)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if res, err := fst.Apply("223"); err != nil {
+				t.Error(err)
+			} else if exp := "tvåhundra tjugotre"; res != exp {
+				t.Errorf(fs, exp, res)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFSTApplySpaces(t *testing.T) {
+	fst := &FST{
+		Start:     0,
+		NumStates: 6,
+		Arcs: []FSTArc{
+			{From: 0, To: 1, In: "1", Out: "a"},
+			{From: 1, To: 2, Out: FSTSpace},
+			{From: 2, To: 3, Out: FSTSpace},
+			{From: 3, To: 4, Out: FSTSpace},
+			{From: 4, To: 5, Out: "b"},
+		},
+		Finals: []int{5},
+	}
+	if res, err := fst.Apply("1"); err != nil {
+		t.Error(err)
+	} else if exp := "a b"; res != exp {
+		t.Errorf(fs, exp, res)
+	}
+}
+
+func TestFSTSymbols(t *testing.T) {
+	g := testGroup(t)
+	fst, err := g.FST("spellout-numbering", 100, 101)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, res := "<eps> <space> ett hundra", strings.Join(fst.OutputSymbols(), " "); res != exp {
+		t.Errorf(fs, exp, res)
+	}
+	if exp, res := 11, len(fst.InputSymbols()); res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	var att strings.Builder
+	if err := fst.WriteATT(&att); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(att.String()), "\n")
+	if exp, res := len(fst.Arcs)+1, len(lines); res != exp {
+		t.Errorf(fs, exp, res)
+	}
+	if fields := strings.Split(lines[0], "\t"); len(fields) != 4 || fields[0] != strconv.Itoa(fst.Start) {
+		t.Errorf("expected an arc from the start state on the first line, got %s", lines[0])
+	}
+	if exp, res := strconv.Itoa(fst.Finals[0]), lines[len(lines)-1]; res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	var syms strings.Builder
+	if err := WriteSymbols(&syms, fst.InputSymbols()); err != nil {
+		t.Fatal(err)
+	}
+	if exp, res := "<eps>\t0\n0\t1\n", syms.String()[:len("<eps>\t0\n0\t1\n")]; res != exp {
+		t.Errorf(fs, exp, res)
+	}
+}
+
+func TestFSTInvalid(t *testing.T) {
+	g := testGroup(t)
+	if _, err := g.FST("spellout-ordinal", 0, 10); err == nil {
+		t.Error("expected error for missing rule set")
+	}
+	if _, err := g.FST("spellout-numbering", 10, 0); err == nil {
+		t.Error("expected error for invalid range")
+	}
+	// the last rule is enumerated, and has no upper limit
+	if _, err := g.FST("spellout-numbering", 0, 100000); err == nil {
+		t.Error("expected error for too many numbers to enumerate")
+	}
+}
+
+func TestFSTRuleCost(t *testing.T) {
+	g := testGroup(t)
+	fst, err := g.FST("spellout-numbering", 0, 399, FSTRuleCost(0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the number of rules applied: 223 uses 100, %%hundreds 2, 2, 20 and 3
+	tests := map[string]float64{"3": 0.5, "7": 0.5, "23": 1, "223": 2.5}
+	for input, exp := range tests {
+		out, weight, err := fst.apply(input)
+		if err != nil {
+			t.Error(err)
+		} else if weight != exp {
+			t.Errorf("%s (%s): "+fs, input, out, exp, weight)
+		}
+	}
+	if err := fst.Check(100, rand.New(rand.NewSource(1))); err != nil {
+		t.Error(err)
+	}
+	var sb strings.Builder
+	if err := fst.WriteATT(&sb); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), "\t<eps>\t<eps>\t0.5\n") {
+		t.Errorf("expected weights in AT&T output")
+	}
+
+	// unweighted by default
+	fst, err = g.FST("spellout-numbering", 0, 399)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, weight, err := fst.apply("223"); err != nil || weight != 0 {
+		t.Errorf("expected weight 0, got %v (%v)", weight, err)
+	}
+}
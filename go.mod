@@ -2,7 +2,4 @@ module github.com/stts-se/rbnf
 
 go 1.16
 
-require (
-	github.com/google/uuid v1.1.1
-	golang.org/x/text v0.3.8
-)
+require golang.org/x/text v0.3.8
@@ -0,0 +1,143 @@
+package rbnf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RuleSetNode is a rule set in a reference graph
+type RuleSetNode struct {
+	Name    string
+	Private bool
+}
+
+// RuleSetEdge is a reference from a rule in one rule set to another rule set, such as
+// 100: <%spellout-cardinal-neuter< hundra[>>];
+type RuleSetEdge struct {
+	From string
+	To   string
+	// Base is the base value of the referring rule (Base.Value)
+	Base string
+	// Operation is the operation of the referring sub (<<, >> or ==)
+	Operation string
+}
+
+// RuleSetGraph is the directed reference graph of a rule set group, with rule sets as nodes
+// and rule references as edges (see RuleSetGroup.Graph)
+type RuleSetGraph struct {
+	Group string
+	Nodes []RuleSetNode
+	Edges []RuleSetEdge
+}
+
+// Graph returns the reference graph of the group. Nodes are sorted by name, and edges are in order of
+// rule set name, rule and sub. References are listed without the % prefix.
+func (g RuleSetGroup) Graph() RuleSetGraph {
+	res := RuleSetGraph{Group: g.Name}
+	for _, rs := range g.RuleSets {
+		res.Nodes = append(res.Nodes, RuleSetNode{Name: rs.Name, Private: rs.Private})
+	}
+	sort.Slice(res.Nodes, func(i, j int) bool { return res.Nodes[i].Name < res.Nodes[j].Name })
+	for _, n := range res.Nodes {
+		for _, r := range g.RuleSets[n.Name].Rules {
+			for _, sub := range r.Subs {
+				if !sub.IsRuleRef() {
+					continue
+				}
+				to := strings.TrimLeft(sub.RuleRef, "%")
+				res.Edges = append(res.Edges, RuleSetEdge{From: n.Name, To: to, Base: r.Base.Value(), Operation: sub.Operation})
+			}
+		}
+	}
+	return res
+}
+
+// ReachableFrom returns the names of the rule sets reachable from the named rule sets (including themselves), sorted by name
+func (gr RuleSetGraph) ReachableFrom(names ...string) []string {
+	edges := make(map[string][]string)
+	for _, e := range gr.Edges {
+		edges[e.From] = append(edges[e.From], e.To)
+	}
+	seen := make(map[string]bool)
+	queue := append([]string{}, names...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		queue = append(queue, edges[name]...)
+	}
+	res := []string{}
+	for name := range seen {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Reachable returns the names of the rule sets reachable from the public rule sets of the graph, sorted by name
+func (gr RuleSetGraph) Reachable() []string {
+	public := []string{}
+	for _, n := range gr.Nodes {
+		if !n.Private {
+			public = append(public, n.Name)
+		}
+	}
+	return gr.ReachableFrom(public...)
+}
+
+// Unreachable returns the names of the private rule sets not reachable from any public rule set, sorted by name
+func (gr RuleSetGraph) Unreachable() []string {
+	reachable := make(map[string]bool)
+	for _, name := range gr.Reachable() {
+		reachable[name] = true
+	}
+	res := []string{}
+	for _, n := range gr.Nodes {
+		if !reachable[n.Name] {
+			res = append(res, n.Name)
+		}
+	}
+	return res
+}
+
+// DOT returns the graph in Graphviz DOT format. Public rule sets are drawn as boxes, private rule sets as
+// ellipses, and unreachable rule sets in gray. Edges between the same rule sets are merged, and labelled
+// with the base values and operations of the referring rules.
+func (gr RuleSetGraph) DOT() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %q {\n", gr.Group)
+	fmt.Fprintf(&b, "  rankdir=LR;\n")
+	reachable := make(map[string]bool)
+	for _, name := range gr.Reachable() {
+		reachable[name] = true
+	}
+	for _, n := range gr.Nodes {
+		attrs := []string{"shape=box"}
+		if n.Private {
+			attrs = []string{"shape=ellipse"}
+		}
+		if !reachable[n.Name] {
+			attrs = append(attrs, "color=gray", "fontcolor=gray")
+		}
+		fmt.Fprintf(&b, "  %q [%s];\n", n.Name, strings.Join(attrs, ", "))
+	}
+	type pair struct{ from, to string }
+	var pairs []pair
+	labels := make(map[pair][]string)
+	for _, e := range gr.Edges {
+		p := pair{e.From, e.To}
+		if _, ok := labels[p]; !ok {
+			pairs = append(pairs, p)
+		}
+		labels[p] = append(labels[p], fmt.Sprintf("%s %s", e.Base, e.Operation))
+	}
+	for _, p := range pairs {
+		fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", p.from, p.to, strings.Join(labels[p], ", "))
+	}
+	fmt.Fprintf(&b, "}\n")
+	return b.String()
+}
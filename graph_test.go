@@ -0,0 +1,50 @@
+package rbnf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRuleSetGraph(t *testing.T) {
+	gr := testGroup(t).Graph()
+
+	if exp, res := 4, len(gr.Nodes); exp != res {
+		t.Errorf(fs, exp, res)
+	}
+	expEdges := []RuleSetEdge{
+		{From: "hundreds", To: "spellout-numbering", Base: "2", Operation: "=="},
+		{From: "spellout-numbering", To: "hundreds", Base: "100", Operation: "<<"},
+	}
+	if len(gr.Edges) != len(expEdges) {
+		t.Errorf(fs, expEdges, gr.Edges)
+	} else {
+		for i, e := range gr.Edges {
+			if e != expEdges[i] {
+				t.Errorf(fs, expEdges[i], e)
+			}
+		}
+	}
+
+	if exp, res := "digits-ordinal hundreds spellout-numbering", strings.Join(gr.Reachable(), " "); exp != res {
+		t.Errorf(fs, exp, res)
+	}
+	if exp, res := "unused", strings.Join(gr.Unreachable(), " "); exp != res {
+		t.Errorf(fs, exp, res)
+	}
+	if exp, res := "hundreds spellout-numbering", strings.Join(gr.ReachableFrom("hundreds"), " "); exp != res {
+		t.Errorf(fs, exp, res)
+	}
+
+	dot := gr.DOT()
+	for _, exp := range []string{
+		`digraph "SpelloutRules" {`,
+		`"spellout-numbering" [shape=box];`,
+		`"hundreds" [shape=ellipse];`,
+		`"unused" [shape=ellipse, color=gray, fontcolor=gray];`,
+		`"hundreds" -> "spellout-numbering" [label="2 =="];`,
+	} {
+		if !strings.Contains(dot, exp) {
+			t.Errorf("expected %s in DOT output, got %s", exp, dot)
+		}
+	}
+}
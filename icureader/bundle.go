@@ -0,0 +1,223 @@
+package icureader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/stts-se/rbnf"
+)
+
+// bundleNode is a table or an array of an ICU4C resource bundle (name{ ... }). Strings of arrays are kept
+// in order; for rules, they are concatenated.
+type bundleNode struct {
+	name     string
+	strings  []string
+	children []*bundleNode
+}
+
+func (n *bundleNode) child(name string) (*bundleNode, bool) {
+	for _, c := range n.children {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// bundleParser parses the text format of ICU4C resource bundles, as read by genrb
+type bundleParser struct {
+	input []rune
+	pos   int
+}
+
+func (p *bundleParser) skipSpace() {
+	for p.pos < len(p.input) {
+		r := p.input[p.pos]
+		if unicode.IsSpace(r) || r == '\uFEFF' {
+			p.pos++
+		} else if p.hasPrefix("//") {
+			for p.pos < len(p.input) && p.input[p.pos] != '\n' {
+				p.pos++
+			}
+		} else if p.hasPrefix("/*") {
+			p.pos += 2
+			for p.pos < len(p.input) && !p.hasPrefix("*/") {
+				p.pos++
+			}
+			p.pos = min(p.pos+2, len(p.input))
+		} else {
+			return
+		}
+	}
+}
+
+func (p *bundleParser) hasPrefix(s string) bool {
+	return strings.HasPrefix(string(p.input[p.pos:min(p.pos+len(s), len(p.input))]), s)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// quoted returns the string starting at the current position (at the opening quote), with escapes resolved
+func (p *bundleParser) quoted() (string, error) {
+	var b strings.Builder
+	p.pos++
+	for p.pos < len(p.input) {
+		r := p.input[p.pos]
+		switch {
+		case r == '"':
+			p.pos++
+			return b.String(), nil
+		case r == '\\' && p.pos+1 < len(p.input):
+			p.pos++
+			e := p.input[p.pos]
+			digits := 0
+			switch e {
+			case 'u':
+				digits = 4
+			case 'U':
+				digits = 8
+			case 'x':
+				digits = 2
+			case 'n':
+				b.WriteRune('\n')
+			case 't':
+				b.WriteRune('\t')
+			default:
+				b.WriteRune(e)
+			}
+			if digits > 0 {
+				if p.pos+digits >= len(p.input) {
+					return "", fmt.Errorf("invalid escape at end of input")
+				}
+				n, err := strconv.ParseUint(string(p.input[p.pos+1:p.pos+1+digits]), 16, 32)
+				if err != nil {
+					return "", fmt.Errorf("invalid escape \\%c%s : %v", e, string(p.input[p.pos+1:p.pos+1+digits]), err)
+				}
+				b.WriteRune(rune(n))
+				p.pos += digits
+			}
+			p.pos++
+		default:
+			b.WriteRune(r)
+			p.pos++
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+// word returns the unquoted key or value at the current position
+func (p *bundleParser) word() string {
+	start := p.pos
+	for p.pos < len(p.input) && !unicode.IsSpace(p.input[p.pos]) && !strings.ContainsRune("{},\"", p.input[p.pos]) {
+		p.pos++
+	}
+	return string(p.input[start:p.pos])
+}
+
+// node parses the contents of a table or array, after the opening brace
+func (p *bundleParser) node(name string) (*bundleNode, error) {
+	res := &bundleNode{name: name}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.input) {
+			return res, fmt.Errorf("missing closing brace for %s", name)
+		}
+		switch r := p.input[p.pos]; r {
+		case '}':
+			p.pos++
+			return res, nil
+		case ',':
+			p.pos++
+		case '{':
+			return res, fmt.Errorf("unexpected opening brace in %s", name)
+		case '"':
+			s, err := p.quoted()
+			if err != nil {
+				return res, fmt.Errorf("failed to read string in %s : %v", name, err)
+			}
+			res.strings = append(res.strings, s)
+		default:
+			w := p.word()
+			p.skipSpace()
+			if p.pos < len(p.input) && p.input[p.pos] == '{' {
+				p.pos++
+				// keys may have a type: RBNFRules:table
+				child, err := p.node(strings.SplitN(w, ":", 2)[0])
+				if err != nil {
+					return res, err
+				}
+				res.children = append(res.children, child)
+			} else {
+				res.strings = append(res.strings, w)
+			}
+		}
+	}
+}
+
+// parseBundle parses the root table of a resource bundle
+func parseBundle(text string) (*bundleNode, error) {
+	p := &bundleParser{input: []rune(text)}
+	p.skipSpace()
+	name := p.word()
+	p.skipSpace()
+	if name == "" || p.pos >= len(p.input) || p.input[p.pos] != '{' {
+		return nil, fmt.Errorf("no root table in resource bundle")
+	}
+	p.pos++
+	return p.node(strings.SplitN(name, ":", 2)[0])
+}
+
+// RulesFromBundle reads a rule package from an ICU4C resource bundle in text format, such as
+// icu4c/source/data/rbnf/sv.txt. The language is the name of the root table, and the rule set groups
+// are the tables in RBNFRules (or, in older bundles, the tables of the root ending with Rules).
+func RulesFromBundle(text string) (rbnf.RulePackage, error) {
+	root, err := parseBundle(text)
+	if err != nil {
+		return rbnf.RulePackage{}, fmt.Errorf("RulesFromBundle: %v", err)
+	}
+	lang := rbnf.Language(root.name)
+	var tables []*bundleNode
+	if rules, ok := root.child("RBNFRules"); ok {
+		tables = rules.children
+	} else {
+		for _, c := range root.children {
+			if strings.HasSuffix(c.name, "Rules") {
+				tables = append(tables, c)
+			}
+		}
+	}
+	if len(tables) == 0 {
+		return rbnf.RulePackage{}, fmt.Errorf("RulesFromBundle: no rules in resource bundle %s", root.name)
+	}
+
+	var groups []rbnf.RuleSetGroup
+	for _, t := range tables {
+		ruleSets, err := ParseRuleSets(strings.Join(t.strings, ""), lang)
+		if err != nil {
+			return rbnf.RulePackage{}, fmt.Errorf("RulesFromBundle: failed to parse rules of rule set group %s : %v", t.name, err)
+		}
+		group, err := rbnf.NewRuleSetGroup(t.name, lang, ruleSets)
+		if err != nil {
+			return rbnf.RulePackage{}, fmt.Errorf("RulesFromBundle: %v", err)
+		}
+		groups = append(groups, group)
+	}
+	return rbnf.NewRulePackage(lang, groups, false)
+}
+
+// RulesFromBundleFile reads a rule package from an ICU4C resource bundle file in text format (see RulesFromBundle)
+func RulesFromBundleFile(fn string) (rbnf.RulePackage, error) {
+	bytes, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return rbnf.RulePackage{}, fmt.Errorf("RulesFromBundleFile: failed to read file : %v", err)
+	}
+	return RulesFromBundle(string(bytes))
+}
@@ -0,0 +1,248 @@
+// Package icureader contains parsers for rules in ICU rule syntax: rule description strings, as used by ICU's
+// RuleBasedNumberFormat(String description), and ICU4C resource bundles (https://github.com/unicode-org/icu/tree/main/icu4c/source/data/rbnf).
+//
+// License for ICU: https://github.com/unicode-org/icu/blob/main/LICENSE
+package icureader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/stts-se/rbnf"
+	"github.com/stts-se/rbnf/lexer"
+)
+
+var Verb = false
+
+// ErrUnsupportedRule is returned by ParseRule for rules in formats not supported by the rbnf package (see README)
+var ErrUnsupportedRule = fmt.Errorf("unsupported rule format")
+
+// DefaultGroupName is the rule set group name of packages read from rule description strings
+const DefaultGroupName = "SpelloutRules"
+
+func replaceChars(s string) string {
+	s = strings.Replace(s, "→", ">", -1)
+	s = strings.Replace(s, "←", "<", -1)
+	s = strings.Replace(s, "−", "-", -1)
+	//s = strings.Replace(s, "­", "", -1) // soft hyphen
+	return s
+}
+
+// arrows replaces the ASCII substitution characters of ICU rule syntax with the arrows of the CLDR files, as expected by the lexer
+var arrows = strings.NewReplacer("<", "←", ">", "→")
+
+var threeArrows = regexp.MustCompile("(→%+[a-z-]*→[a-z-]*→|←%+[a-z-]*←[a-z-]*←)")
+
+func unsupportedRuleFormat(rFmt string) bool {
+	return strings.Contains(rFmt, "ignorable") ||
+		//strings.Contains(rFmt, "$") ||
+		strings.Contains(rFmt, "→→→") ||
+		threeArrows.MatchString(rFmt)
+}
+
+// ParseRule parses a rule from its base value (1000, 1100>, -x, x.x), radix (empty for the default, 10) and
+// rule text (<< hundred[ >>];), using either arrows or ASCII characters for substitutions. ErrUnsupportedRule
+// is returned for rules in unsupported formats, such as lenient-parse rules.
+func ParseRule(value, radix, text string, lang rbnf.Language) (rbnf.BaseRule, error) {
+	rule := rbnf.BaseRule{}
+	v := strings.Replace(value, ",", "", -1)
+	// each > after the base value decreases the exponent of the divisor
+	decrement := len(v) - len(strings.TrimRight(v, ">"))
+	baseNum, err := strconv.Atoi(strings.TrimRight(v, ">"))
+	if err == nil { // numeric rule
+		r := 10 // Default radix
+		if radix != "" {
+			r, err = strconv.Atoi(strings.Replace(radix, ",", "", -1))
+			if err != nil {
+				return rule, fmt.Errorf("failed to convert radix : %v", err)
+			}
+		}
+		rule.Base = rbnf.NewBaseInt(baseNum, r)
+		rule.Base.Decrement = decrement
+	} else { // non-numeric rule
+		rule.Base = rbnf.NewBaseString(value)
+	}
+
+	text = arrows.Replace(text)
+	if unsupportedRuleFormat(text) {
+		return rule, ErrUnsupportedRule
+	}
+
+	lex := lexer.Lex(text)
+	if err := lex.Run(); err != nil {
+		return rule, fmt.Errorf("parse failed for '%s' : %v", text, err)
+	}
+	for _, i := range lex.Result() {
+		sub, err := rbnf.ParseSub(replaceChars(i), lang)
+		if err != nil {
+			return rule, err
+		}
+		rule.Subs = append(rule.Subs, sub)
+	}
+	return rule, nil
+}
+
+// ruleSetHeader matches the name of a rule set in ICU rule syntax: %spellout-numbering: or %%private-name:
+var ruleSetHeader = regexp.MustCompile(`^(%%?)([^:%\s]+):`)
+
+// ruleDescriptor matches the base value of a rule in ICU rule syntax: 100:, 1,000:, 1100/100:, 100>:, -x:, x.x:
+var ruleDescriptor = regexp.MustCompile(`^(-x|x\.x|x,x|0\.x|x\.0|Inf|NaN|[0-9][0-9,]*(?:/[0-9,]+)?>*)\s*:`)
+
+// splitRules splits text in ICU rule syntax at the semicolons ending rules. An apostrophe after whitespace or at
+// the start of the rule text opens quoted text up to the next apostrophe ('x;y', << ' ' <<), in which semicolons
+// don't end the rule. An apostrophe followed by whitespace at the start of the rule text only marks leading
+// spaces (' hundred), and other apostrophes are plain text (=#,##0=':e).
+func splitRules(text string) ([]string, error) {
+	var res []string
+	for strings.TrimSpace(text) != "" {
+		// find the start of the rule text, after rule set name and base value
+		body := strings.TrimLeft(text, " \t\r\n")
+		if m := ruleSetHeader.FindString(body); m != "" {
+			body = strings.TrimLeft(body[len(m):], " \t\r\n")
+		}
+		if m := ruleDescriptor.FindString(body); m != "" {
+			body = strings.TrimLeft(body[len(m):], " \t\r\n")
+		}
+		if r := []rune(body); len(r) > 1 && r[0] == '\'' && unicode.IsSpace(r[1]) {
+			body = body[1:]
+		}
+		offset := len(text) - len(body)
+
+		end, quoted, prev := len(body), false, ' '
+		for i, ch := range body {
+			if quoted {
+				quoted = ch != '\''
+			} else if ch == '\'' && unicode.IsSpace(prev) {
+				quoted = true
+			} else if ch == ';' {
+				end = i
+				break
+			}
+			prev = ch
+		}
+		if quoted {
+			return res, fmt.Errorf("unterminated quote in rule : %s", strings.TrimSpace(text[:offset+end]))
+		}
+		res = append(res, text[:offset+end])
+		if offset+end == len(text) {
+			break
+		}
+		text = text[offset+end+1:]
+	}
+	return res, nil
+}
+
+// ParseRuleSets parses rule sets in ICU rule syntax, as in rule description strings and the rbnfRules element
+// of CLDR 44 and later. Rules are separated by semicolons, except in quoted text ('x;y'), and rule sets start
+// with their name (%name: or, if private, %%name:). Rules without a base value get the previous rule's base value
+// plus one (or 0 for the first rule). Rules before the first rule set name are put in a rule set named default.
+// Rules in unsupported formats, including rules with semicolons in quoted text, are skipped, and rule sets
+// without rules (such as lenient-parse) are left out.
+func ParseRuleSets(text string, lang rbnf.Language) ([]rbnf.RuleSet, error) {
+	var res []rbnf.RuleSet
+	var current *rbnf.RuleSet
+	var ruleSets []*rbnf.RuleSet
+	chunks, err := splitRules(text)
+	if err != nil {
+		return res, err
+	}
+	next := 0
+	for _, chunk := range chunks {
+		rule := strings.TrimSpace(chunk)
+		if rule == "" {
+			continue
+		}
+		if m := ruleSetHeader.FindStringSubmatch(rule); m != nil {
+			current = &rbnf.RuleSet{Name: m[2], Private: m[1] == "%%"}
+			ruleSets = append(ruleSets, current)
+			next = 0
+			rule = strings.TrimSpace(rule[len(m[0]):])
+			if rule == "" {
+				continue
+			}
+		} else if strings.HasPrefix(rule, "%") {
+			return res, fmt.Errorf("invalid rule set name : %s", rule)
+		}
+		if current == nil {
+			current = &rbnf.RuleSet{Name: "default"}
+			ruleSets = append(ruleSets, current)
+		}
+
+		var value, radix string
+		if m := ruleDescriptor.FindStringSubmatch(rule); m != nil {
+			vr := strings.SplitN(m[1], "/", 2)
+			value = vr[0]
+			if len(vr) == 2 {
+				radix = strings.TrimRight(vr[1], ">")
+				value += vr[1][len(radix):]
+			}
+			rule = strings.TrimLeft(rule[len(m[0]):], " \t\r\n")
+			if n, err := strconv.Atoi(strings.Replace(strings.TrimRight(value, ">"), ",", "", -1)); err == nil {
+				next = n + 1
+			}
+		} else {
+			value = strconv.Itoa(next)
+			next++
+		}
+
+		var r rbnf.BaseRule
+		var err error
+		if strings.Contains(rule, ";") {
+			// a semicolon in quoted text would end the rule in the lexer
+			err = ErrUnsupportedRule
+		} else {
+			r, err = ParseRule(value, radix, rule+";", lang)
+		}
+		if err == ErrUnsupportedRule {
+			if Verb {
+				log.Printf("[icureader] skipping unsupported rule format: %s", rule)
+			}
+			continue
+		}
+		if err != nil {
+			return res, fmt.Errorf("failed to parse rule in rule set %s : %v", current.Name, err)
+		}
+		current.Rules = append(current.Rules, r)
+	}
+	for _, rs := range ruleSets {
+		if len(rs.Rules) > 0 {
+			res = append(res, *rs)
+		}
+	}
+	return res, nil
+}
+
+// RulesFromDescription reads a rule package from a rule description string, as used by ICU's
+// RuleBasedNumberFormat(String description). The rule sets are put in a group named groupName
+// (DefaultGroupName if empty).
+func RulesFromDescription(description string, lang rbnf.Language, groupName string) (rbnf.RulePackage, error) {
+	if groupName == "" {
+		groupName = DefaultGroupName
+	}
+	ruleSets, err := ParseRuleSets(description, lang)
+	if err != nil {
+		return rbnf.RulePackage{}, fmt.Errorf("RulesFromDescription: %v", err)
+	}
+	group, err := rbnf.NewRuleSetGroup(groupName, lang, ruleSets)
+	if err != nil {
+		return rbnf.RulePackage{}, fmt.Errorf("RulesFromDescription: %v", err)
+	}
+	return rbnf.NewRulePackage(lang, []rbnf.RuleSetGroup{group}, false)
+}
+
+// RulesFromDescriptionFile reads a rule package from a file holding a rule description string. The language
+// is the base name of the file (sv.rbnf: sv), and the rule set group is named DefaultGroupName.
+func RulesFromDescriptionFile(fn string) (rbnf.RulePackage, error) {
+	bytes, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return rbnf.RulePackage{}, fmt.Errorf("RulesFromDescriptionFile: failed to read file : %v", err)
+	}
+	lang := strings.TrimSuffix(filepath.Base(fn), filepath.Ext(fn))
+	return RulesFromDescription(string(bytes), rbnf.Language(lang), DefaultGroupName)
+}
@@ -0,0 +1,270 @@
+package icureader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stts-se/rbnf"
+)
+
+var fs = "Expected '%v', got '%v'"
+
+func TestParseRule(t *testing.T) {
+	arrows, err := ParseRule("100", "", "←← hundra[ →→];", "sv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ascii, err := ParseRule("100", "", "<< hundra[ >>];", "sv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, res := arrows.String(), ascii.String(); res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	r, err := ParseRule("1,100>", "100", "<<hundra[>>];", "sv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Base.Int != 1100 || r.Base.Radix != 100 || r.Base.Decrement != 1 || r.Base.Divisor() != 1 {
+		t.Errorf("expected 1100/100> with divisor 1, got %#v (divisor %d)", r.Base, r.Base.Divisor())
+	}
+
+	if r, err := ParseRule("x.x", "", "<< komma >>;", "sv"); err != nil {
+		t.Error(err)
+	} else if exp, res := "x.x", r.Base.String; res != exp {
+		t.Errorf(fs, exp, res)
+	}
+	if _, err := ParseRule("0", "", "&[last primary ignorable ] << ' ' << ',';", "sv"); err != ErrUnsupportedRule {
+		t.Errorf(fs, ErrUnsupportedRule, err)
+	}
+	if _, err := ParseRule("10", "x", "tio;", "sv"); err == nil {
+		t.Error("expected error for invalid radix")
+	}
+}
+
+func TestParseRuleSets(t *testing.T) {
+	ascii := `
+%spellout-numbering:
+-x: minus >>;
+0: noll;
+ett;
+två;
+1,000: << tusen[ >>];
+1100/100>: <<hundra[>>];
+%%private:
+'  mellanslag;
+x.x: =0.0=:e;
+%%lenient-parse:
+&[last primary ignorable ] << ' ' << ',' << '-';
+`
+	// the same rules, using the arrows of the older CLDR layout for substitutions
+	arrows := `
+%spellout-numbering:
+-x: minus →→;
+0: noll;
+ett;
+två;
+1,000: ←← tusen[ →→];
+1100/100>: ←←hundra[→→];
+%%private:
+'  mellanslag;
+x.x: =0.0=:e;
+%%lenient-parse:
+&[last primary ignorable ] ←← ' ' ←← ',' ←← '-';
+`
+	exp := []string{
+		"spellout-numbering false",
+		"-x => 'minus >>'",
+		"0 (10) => 'noll'",
+		"1 (10) => 'ett'",
+		"2 (10) => 'två'",
+		"1000 (10) => '<< tusen[ ][>>]'",
+		"1100> (100) => '<<hundra[>>]'",
+		"private true",
+		"0 (10) => ''  mellanslag'",
+		"x.x => '=0.0=:e'",
+	}
+	for _, text := range []string{ascii, arrows} {
+		ruleSets, err := ParseRuleSets(text, "sv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var res []string
+		for _, rs := range ruleSets {
+			res = append(res, fmt.Sprintf("%s %v", rs.Name, rs.Private))
+			for _, r := range rs.Rules {
+				res = append(res, r.String())
+			}
+		}
+		if strings.Join(res, "\n") != strings.Join(exp, "\n") {
+			t.Errorf(fs, strings.Join(exp, "\n"), strings.Join(res, "\n"))
+		}
+		base := ruleSets[0].Rules[len(ruleSets[0].Rules)-1].Base
+		if base.Int != 1100 || base.Radix != 100 || base.Decrement != 1 || base.Divisor() != 1 {
+			t.Errorf("expected 1100/100> with divisor 1, got %#v (divisor %d)", base, base.Divisor())
+		}
+	}
+
+	ruleSets, err := ParseRuleSets("noll; ett; 10: tio;", "sv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ruleSets) != 1 || ruleSets[0].Name != "default" || len(ruleSets[0].Rules) != 3 {
+		t.Errorf("expected a default rule set with 3 rules, got %#v", ruleSets)
+	}
+
+	if _, err := ParseRuleSets("%: noll;", "sv"); err == nil {
+		t.Error("expected error for invalid rule set name")
+	}
+}
+
+const testDescription = `%spellout-numbering:
+    -x: minus >>;
+    0: noll; ett; två; tre; fyra; fem; sex; sju; åtta; nio;
+    10: tio; elva; tolv;
+    20: tjugo[>>];
+    100: <%%hundreds<[ >>];
+%%hundreds:
+    1: hundra; =%spellout-numbering=hundra;
+`
+
+func TestRulesFromDescription(t *testing.T) {
+	pack, err := RulesFromDescription(testDescription, "sv", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"0":    "noll",
+		"-12":  "minus tolv",
+		"23":   "tjugotre",
+		"100":  "hundra",
+		"221":  "tvåhundra tjugoett",
+		"1000": "tiohundra",
+	}
+	for input, exp := range tests {
+		res, err := pack.Spellout(input, DefaultGroupName, "spellout-numbering", false)
+		if err != nil {
+			t.Error(err)
+		} else if res != exp {
+			t.Errorf(fs, exp, res)
+		}
+	}
+
+	if _, err := RulesFromDescription("0: =%missing=;", "sv", ""); err == nil {
+		t.Error("expected error for missing rule set reference")
+	}
+
+	dir, err := ioutil.TempDir("", "icureader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fn := filepath.Join(dir, "sv.rbnf")
+	if err := ioutil.WriteFile(fn, []byte(testDescription), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pack, err = RulesFromDescriptionFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, res := rbnf.Language("sv"), pack.Language; res != exp {
+		t.Errorf(fs, exp, res)
+	}
+}
+
+func TestRulesFromBundle(t *testing.T) {
+	pack, err := RulesFromBundleFile("test_data/sv.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, res := rbnf.Language("sv"), pack.Language; res != exp {
+		t.Errorf(fs, exp, res)
+	}
+	tests := []struct{ group, ruleSet, input, exp string }{
+		{"SpelloutRules", "spellout-numbering", "-21", "minus tjugo­ett"},
+		{"SpelloutRules", "spellout-numbering-year", "1984", "nitton­hundra­åttio­fyra"},
+		{"OrdinalRules", "digits-ordinal", "2", "2:a"},
+	}
+	for _, test := range tests {
+		res, err := pack.Spellout(test.input, test.group, test.ruleSet, false)
+		if err != nil {
+			t.Error(err)
+		} else if res != test.exp {
+			t.Errorf(fs, test.exp, res)
+		}
+	}
+
+	bundle := `// comment
+de:table{
+    /* old layout, without RBNFRules */
+    SpelloutRules{
+        "%spellout-numbering:"
+        "0: null; eins; zwei;"
+        "3: drei­mal;"
+    }
+    Version{"2.1"}
+}`
+	pack, err = RulesFromBundle(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := pack.Spellout("3", "SpelloutRules", "spellout-numbering", false); err != nil {
+		t.Error(err)
+	} else if exp := "drei­mal"; res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	for _, invalid := range []string{"", "de{", `de{ SpelloutRules{ "0: null; }}`, `de{ Version{"1"} }`, `de{ SpelloutRules{ "%: null;" } }`} {
+		if _, err := RulesFromBundle(invalid); err == nil {
+			t.Errorf("expected error for bundle %s", invalid)
+		}
+	}
+}
+
+func TestSplitRules(t *testing.T) {
+	tests := []struct {
+		text   string
+		expect []string
+	}{
+		{"1: 'x;y'; 2: z;", []string{"1: 'x;y'", "2: z"}},
+		{"%a:\n0: noll; ett;", []string{"%a:\n0: noll", "ett"}},
+		{"1: ' =%spellout-ordinal=; 2: =#,##0=':e;", []string{"1: ' =%spellout-ordinal=", "2: =#,##0=':e"}},
+		{"'  mellanslag; x.x: =0.0=:e", []string{"'  mellanslag", "x.x: =0.0=:e"}},
+		{"&[last primary ignorable ] << ' ' << ';' << '-'; 0: noll;", []string{"&[last primary ignorable ] << ' ' << ';' << '-'", "0: noll"}},
+	}
+	for _, test := range tests {
+		rules, err := splitRules(test.text)
+		if err != nil {
+			t.Errorf("%s : %v", test.text, err)
+			continue
+		}
+		var res []string
+		for _, r := range rules {
+			res = append(res, strings.TrimSpace(r))
+		}
+		if exp, res := strings.Join(test.expect, " | "), strings.Join(res, " | "); res != exp {
+			t.Errorf(fs, exp, res)
+		}
+	}
+	if _, err := splitRules("1: 'x;y; 2: z;"); err == nil {
+		t.Error("expected error for unterminated quote")
+	}
+
+	// the lexer can't read semicolons in rule text, so the quoted rule is skipped, not split in two
+	ruleSets, err := ParseRuleSets("%a: 0: noll; 1: 'x;y'; 2: två;", "sv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res []string
+	for _, r := range ruleSets[0].Rules {
+		res = append(res, r.String())
+	}
+	if exp, res := "0 (10) => 'noll' | 2 (10) => 'två'", strings.Join(res, " | "); res != exp {
+		t.Errorf(fs, exp, res)
+	}
+}
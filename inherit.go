@@ -0,0 +1,47 @@
+package rbnf
+
+import (
+	"fmt"
+)
+
+// InheritRules merges a rule package with the packages of its parent locales, ordered from the nearest
+// parent to root (for de_CH: de, root), as CLDR rule files for regional locales are layered on their
+// parents. Rule set groups are merged by name. Within a group, a rule set in the child replaces the
+// parent's rule set with the same name, and the parent's other rule sets are added to the group. Groups
+// found only in parents (such as the roman numerals of root) are added to the package.
+//
+// Since rule references are resolved within the merged group, references in the child can point to
+// rule sets defined by a parent, and references in a parent resolve to the child's rule set if the child
+// overrides it. The packages don't need to be valid on their own, but the merged package is validated.
+// The merged package has the child's language and settings.
+func InheritRules(child RulePackage, parents ...RulePackage) (RulePackage, error) {
+	var names []string
+	merged := make(map[string]map[string]RuleSet)
+	layers := append([]RulePackage{child}, parents...)
+	for _, pkg := range layers {
+		for _, g := range pkg.RuleSetGroups {
+			ruleSets, ok := merged[g.Name]
+			if !ok {
+				names = append(names, g.Name)
+				ruleSets = make(map[string]RuleSet)
+				merged[g.Name] = ruleSets
+			}
+			for name, rs := range g.RuleSets {
+				if _, ok := ruleSets[name]; !ok {
+					ruleSets[name] = rs
+				}
+			}
+		}
+	}
+
+	res := child
+	res.RuleSetGroups = nil
+	for _, name := range names {
+		g := RuleSetGroup{Name: name, Language: child.Language, RuleSets: merged[name]}
+		if err := g.Validate(); err != nil {
+			return RulePackage{}, fmt.Errorf("invalid rule set group %s after inheritance : %v", name, err)
+		}
+		res.RuleSetGroups = append(res.RuleSetGroups, g)
+	}
+	return res, nil
+}
@@ -0,0 +1,84 @@
+package rbnf
+
+import (
+	"testing"
+)
+
+func TestInheritRules(t *testing.T) {
+	root := RulePackage{
+		Language: "root",
+		RuleSetGroups: []RuleSetGroup{
+			{Name: "SpelloutRules", Language: "root", RuleSets: map[string]RuleSet{
+				"spellout-numbering": {Name: "spellout-numbering", Rules: []BaseRule{NewIntRule("root", 0, 10, "=#,##0=")}},
+			}},
+			{Name: "NumberingSystemRules", Language: "root", RuleSets: map[string]RuleSet{
+				"roman-upper": {Name: "roman-upper", Rules: []BaseRule{
+					NewIntRule("root", 1, 10, "I"),
+					NewIntRule("root", 2, 10, "II"),
+				}},
+			}},
+		},
+	}
+	de := RulePackage{
+		Language: "de",
+		RuleSetGroups: []RuleSetGroup{
+			{Name: "SpelloutRules", Language: "de", RuleSets: map[string]RuleSet{
+				"spellout-numbering": {Name: "spellout-numbering", Rules: []BaseRule{
+					NewIntRule("de", 0, 10, "null"),
+					NewIntRule("de", 1, 10, "eins"),
+					NewIntRule("de", 2, 10, "zwei"),
+				}},
+				"spellout-numbering-year": {Name: "spellout-numbering-year", Rules: []BaseRule{NewIntRule("de", 0, 10, "=%spellout-numbering=")}},
+			}},
+		},
+	}
+	// de_CH refers to a rule set defined in de, and overrides spellout-numbering
+	deCH := RulePackage{
+		Language: "de",
+		RuleSetGroups: []RuleSetGroup{
+			{Name: "SpelloutRules", Language: "de", RuleSets: map[string]RuleSet{
+				"spellout-numbering": {Name: "spellout-numbering", Rules: []BaseRule{
+					NewIntRule("de", 0, 10, "nüt"),
+					NewIntRule("de", 1, 10, "eis"),
+				}},
+				"spellout-cardinal": {Name: "spellout-cardinal", Rules: []BaseRule{NewIntRule("de", 0, 10, "=%spellout-numbering-year=")}},
+			}},
+		},
+	}
+
+	pkg, err := InheritRules(deCH, de, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, res := 2, len(pkg.RuleSetGroups); exp != res {
+		t.Errorf(fs, exp, res)
+	}
+	if exp, res := 3, len(pkg.RuleSetGroups[0].RuleSets); exp != res {
+		t.Errorf(fs, exp, res)
+	}
+
+	tests := []struct {
+		group   string
+		ruleSet string
+		input   string
+		exp     string
+	}{
+		{"SpelloutRules", "spellout-numbering", "1", "eis"},
+		{"SpelloutRules", "spellout-numbering-year", "0", "nüt"},
+		{"SpelloutRules", "spellout-cardinal", "1", "eis"},
+		{"NumberingSystemRules", "roman-upper", "2", "II"},
+	}
+	for _, test := range tests {
+		res, err := pkg.Spellout(test.input, test.group, test.ruleSet, false)
+		if err != nil {
+			t.Error(err)
+		} else if res != test.exp {
+			t.Errorf(fs, test.exp, res)
+		}
+	}
+
+	// unresolved references are reported
+	if _, err := InheritRules(deCH, root); err == nil {
+		t.Error("expected error here")
+	}
+}
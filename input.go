@@ -0,0 +1,230 @@
+package rbnf
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/message"
+)
+
+// NumberSymbols holds the decimal and grouping symbols of a locale, used for parsing localized numeric input
+type NumberSymbols struct {
+	Decimal string
+	Group   string
+	// SecondaryGroupSize is the size of digit groups above the thousands (2 for Indian style 12,34,567, otherwise 3)
+	SecondaryGroupSize int
+}
+
+// spaces accepted as grouping symbol wherever the locale groups with a space
+var groupSpaces = []string{" ", " ", " ", " "}
+
+// lenient grouping symbols, accepted unless used as decimal symbol by the locale
+var lenientGroupSymbols = []string{",", ".", "'", "’", "٬"}
+
+var minusSigns = []string{"-", "−", "﹣", "－"}
+
+// digitValue returns the value of a Unicode decimal digit (any script)
+func digitValue(r rune) (int, bool) {
+	if !unicode.Is(unicode.Nd, r) {
+		return 0, false
+	}
+	// decimal digits are encoded in contiguous runs starting with zero
+	zero := r
+	for unicode.Is(unicode.Nd, zero-1) {
+		zero--
+	}
+	return int(r-zero) % 10, true
+}
+
+// asciiDigits converts decimal digits of any script into ASCII digits
+func asciiDigits(s string) string {
+	var res strings.Builder
+	for _, r := range s {
+		if v, ok := digitValue(r); ok {
+			res.WriteRune(rune('0' + v))
+		} else {
+			res.WriteRune(r)
+		}
+	}
+	return res.String()
+}
+
+// LocaleNumberSymbols returns the CLDR decimal and grouping symbols for a language, as used by golang.org/x/text/message
+func LocaleNumberSymbols(lang Language) NumberSymbols {
+	p := message.NewPrinter(lang.Tag())
+	res := NumberSymbols{Decimal: ".", Group: ",", SecondaryGroupSize: 3}
+
+	// 0.5 => 0<decimal>5
+	dec := asciiDigits(p.Sprint(0.5))
+	if i, j := strings.Index(dec, "0"), strings.LastIndex(dec, "5"); i >= 0 && j > i+1 {
+		res.Decimal = dec[i+1 : j]
+	}
+
+	// 1234567 => 1<group>234<group>567 or 12<group>34<group>567
+	grp := asciiDigits(p.Sprint(1234567))
+	var runs []string
+	var seps []string
+	for _, part := range strings.FieldsFunc(grp, func(r rune) bool { return r < '0' || r > '9' }) {
+		runs = append(runs, part)
+	}
+	for _, part := range strings.FieldsFunc(grp, func(r rune) bool { return r >= '0' && r <= '9' }) {
+		seps = append(seps, part)
+	}
+	if len(runs) >= 2 && len(seps) >= 1 {
+		res.Group = seps[len(seps)-1]
+		res.SecondaryGroupSize = len(runs[1])
+		if len(runs) == 2 {
+			res.SecondaryGroupSize = 3
+		}
+	}
+	return res
+}
+
+func isBidiControl(r rune) bool {
+	return unicode.Is(unicode.Bidi_Control, r)
+}
+
+// ParseNumber parses localized numeric input into the canonical input format of the rule matcher
+// (ASCII digits, optional leading '-' and optional '.' for decimals). Fraction digits are kept as they
+// are, including trailing zeros. Digits may be written in any Unicode script, and the sign as '+', '-'
+// or '−' (U+2212).
+//
+// In lenient mode, any of the locale's grouping symbol, spaces, ',', '.', and apostrophes are accepted
+// as grouping symbols (unless used as the locale's decimal symbol), without checking group sizes. A
+// decimal symbol occurring more than once is taken to be a grouping symbol (1.000.000 in English).
+// In strict mode, only the locale's own grouping symbol is accepted (if it is a space, any type of
+// space is), and digit groups must have the sizes used by the locale.
+func ParseNumber(input string, symbols NumberSymbols, strict bool) (string, error) {
+	s := strings.TrimSpace(strings.Map(func(r rune) rune {
+		if isBidiControl(r) {
+			return -1
+		}
+		return r
+	}, input))
+	s = asciiDigits(s)
+
+	neg := false
+	if strings.HasPrefix(s, "+") {
+		s = strings.TrimPrefix(s, "+")
+	} else {
+		for _, m := range minusSigns {
+			if strings.HasPrefix(s, m) {
+				neg = true
+				s = strings.TrimPrefix(s, m)
+				break
+			}
+		}
+	}
+
+	groupSymbols := []string{symbols.Group}
+	if strings.TrimSpace(symbols.Group) == "" {
+		groupSymbols = append(groupSymbols, groupSpaces...)
+	}
+	if !strict {
+		groupSymbols = append(groupSymbols, groupSpaces...)
+		groupSymbols = append(groupSymbols, lenientGroupSymbols...)
+	}
+
+	decimal := symbols.Decimal
+	if n := strings.Count(s, decimal); n > 1 && !strict {
+		for _, g := range lenientGroupSymbols {
+			if g == decimal {
+				decimal = ""
+				break
+			}
+		}
+	}
+
+	intPart, fracPart := s, ""
+	if decimal != "" {
+		switch strings.Count(s, decimal) {
+		case 0:
+		case 1:
+			i := strings.Index(s, decimal)
+			intPart, fracPart = s[:i], s[i+len(decimal):]
+		default:
+			return "", fmt.Errorf("invalid numeric input %s : multiple decimal symbols", input)
+		}
+	}
+
+	// split the integer part into digit groups
+	var groups []string
+	current := ""
+	for i := 0; i < len(intPart); {
+		if c := intPart[i]; c >= '0' && c <= '9' {
+			current += string(c)
+			i++
+			continue
+		}
+		sep := ""
+		for _, g := range groupSymbols {
+			if g != "" && g != decimal && strings.HasPrefix(intPart[i:], g) {
+				sep = g
+				break
+			}
+		}
+		if sep == "" || current == "" {
+			return "", fmt.Errorf("invalid numeric input %s", input)
+		}
+		groups = append(groups, current)
+		current = ""
+		i += len(sep)
+	}
+	if current == "" {
+		return "", fmt.Errorf("invalid numeric input %s", input)
+	}
+	groups = append(groups, current)
+	if strings.Trim(fracPart, "0123456789") != "" {
+		return "", fmt.Errorf("invalid numeric input %s", input)
+	}
+
+	if strict && len(groups) > 1 {
+		for i, g := range groups {
+			switch {
+			case i == 0:
+				if len(g) > 3 {
+					return "", fmt.Errorf("invalid digit grouping in numeric input %s", input)
+				}
+			case i == len(groups)-1:
+				if len(g) != 3 {
+					return "", fmt.Errorf("invalid digit grouping in numeric input %s", input)
+				}
+			default:
+				if len(g) != symbols.SecondaryGroupSize {
+					return "", fmt.Errorf("invalid digit grouping in numeric input %s", input)
+				}
+			}
+		}
+		if len(groups) > 2 && len(groups[0]) > symbols.SecondaryGroupSize {
+			return "", fmt.Errorf("invalid digit grouping in numeric input %s", input)
+		}
+	}
+
+	// fraction digits are kept as they are, including trailing zeros
+	res := strings.TrimLeft(strings.Join(groups, ""), "0")
+	if res == "" {
+		res = "0"
+	}
+	if fracPart != "" {
+		res = res + "." + fracPart
+	}
+	if neg && strings.Trim(res, "0.") != "" {
+		res = "-" + res
+	}
+	return res, nil
+}
+
+// NormalizeInput parses localized numeric input using the package language's number symbols (see ParseNumber)
+func (r *RulePackage) NormalizeInput(input string, strict bool) (string, error) {
+	return ParseNumber(input, LocaleNumberSymbols(r.Language), strict)
+}
+
+// SpelloutLocalized normalizes localized numeric input (see NormalizeInput), and spells it out using the named rule set group and rule set
+func (r *RulePackage) SpelloutLocalized(input string, strict bool, groupName string, ruleSetName string, debug bool) (string, error) {
+	normalized, err := r.NormalizeInput(input, strict)
+	if err != nil {
+		return "", err
+	}
+	return r.Spellout(normalized, groupName, ruleSetName, debug)
+}
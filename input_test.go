@@ -0,0 +1,131 @@
+package rbnf
+
+import (
+	"testing"
+)
+
+func TestLocaleNumberSymbols(t *testing.T) {
+	tests := []struct {
+		lang Language
+		exp  NumberSymbols
+	}{
+		{"en", NumberSymbols{Decimal: ".", Group: ",", SecondaryGroupSize: 3}},
+		{"de", NumberSymbols{Decimal: ",", Group: ".", SecondaryGroupSize: 3}},
+		{"de_CH", NumberSymbols{Decimal: ".", Group: "’", SecondaryGroupSize: 3}},
+		{"sv", NumberSymbols{Decimal: ",", Group: " ", SecondaryGroupSize: 3}},
+		{"hi", NumberSymbols{Decimal: ".", Group: ",", SecondaryGroupSize: 2}},
+		{"ar", NumberSymbols{Decimal: "٫", Group: "٬", SecondaryGroupSize: 3}},
+	}
+	for _, test := range tests {
+		res := LocaleNumberSymbols(test.lang)
+		if res != test.exp {
+			t.Errorf("%s: "+fs, test.lang, test.exp, res)
+		}
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		lang   Language
+		input  string
+		strict bool
+		exp    string
+	}{
+		{"en", "1,000,000", true, "1000000"},
+		{"en", "1 000 000", false, "1000000"},
+		{"en", "1.000.000", false, "1000000"},
+		{"en", "+42", true, "42"},
+		{"en", "−5", true, "-5"},
+		{"en", "-0", true, "0"},
+		{"en", "2.50", true, "2.50"},
+		{"en", "007", true, "7"},
+		{"de", "1.000.000,5", true, "1000000.5"},
+		{"de", "1.000.000,5", false, "1000000.5"},
+		{"de", "-1,25", true, "-1.25"},
+		{"sv", "1 000 000,5", true, "1000000.5"},
+		{"sv", "1 000", true, "1000"},
+		{"de_CH", "1’234.5", true, "1234.5"},
+		{"ar", "١٢٣", true, "123"},
+		{"ar", "؜-١٬٢٣٤٫٥", true, "-1234.5"},
+		{"hi", "१२३", true, "123"},
+		{"hi", "12,34,567", true, "1234567"},
+		{"bn", "১,২৩,৪৫৬.৭৮", true, "123456.78"},
+	}
+	for _, test := range tests {
+		res, err := ParseNumber(test.input, LocaleNumberSymbols(test.lang), test.strict)
+		if err != nil {
+			t.Errorf("%s %s: %v", test.lang, test.input, err)
+		} else if res != test.exp {
+			t.Errorf("%s %s: "+fs, test.lang, test.input, test.exp, res)
+		}
+	}
+
+	errorTests := []struct {
+		lang   Language
+		input  string
+		strict bool
+	}{
+		{"en", "1 000 000", true},
+		{"en", "1.000.000", true},
+		{"en", "1,5", true},
+		{"en", "10,00", true},
+		{"en", "1,0000", true},
+		{"en", "1234,567", true},
+		{"hi", "1,234,567", true},
+		{"de", "1,000,5", true},
+		{"en", "12x", false},
+		{"en", "", false},
+		{"en", "1..2", false},
+		{"en", "1,,000", false},
+		{"en", "1.5e3", false},
+	}
+	for _, test := range errorTests {
+		res, err := ParseNumber(test.input, LocaleNumberSymbols(test.lang), test.strict)
+		if err == nil {
+			t.Errorf("%s %s: expected error, got %s", test.lang, test.input, res)
+		}
+	}
+}
+
+func TestSpelloutLocalized(t *testing.T) {
+	lang := Language("de")
+	numbering := RuleSet{
+		Name: "spellout-numbering",
+		Rules: []BaseRule{
+			NewStringRule(lang, "-x", "minus", " ", ">>"),
+			NewStringRule(lang, "x.x", "<<", " ", "Komma", " ", ">>"),
+			NewIntRule(lang, 0, 10, "null"),
+			NewIntRule(lang, 1, 10, "eins"),
+			NewIntRule(lang, 2, 10, "zwei"),
+			NewIntRule(lang, 3, 10, "drei"),
+			NewIntRule(lang, 4, 10, "vier"),
+			NewIntRule(lang, 5, 10, "fünf"),
+			NewIntRule(lang, 6, 10, "sechs"),
+			NewIntRule(lang, 7, 10, "sieben"),
+			NewIntRule(lang, 8, 10, "acht"),
+			NewIntRule(lang, 9, 10, "neun"),
+			NewIntRule(lang, 10, 10, "zehn"),
+			NewIntRule(lang, 1000, 10, "<<", "tausend", "[>>]"),
+		},
+	}
+	g, err := NewRuleSetGroup("SpelloutRules", lang, []RuleSet{numbering})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := NewRulePackage(lang, []RuleSetGroup{g}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := pkg.SpelloutLocalized("−2.000,5", true, "SpelloutRules", "spellout-numbering", false)
+	if exp := "minus zweitausend Komma fünf"; err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	_, err = pkg.SpelloutLocalized("2,000.5", true, "SpelloutRules", "spellout-numbering", false)
+	if err == nil {
+		t.Error("expected error here")
+	}
+}
@@ -0,0 +1,260 @@
+package rbnf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// JSON schema
+//
+// A RulePackage is marshalled into JSON as follows (fields with zero values are omitted). Rule sets are
+// listed in order of name. Numeric and plural formatters are stored as their pattern strings, together with
+// the language they format for, and are rebuilt when the JSON is unmarshalled. Unmarshalled rule set
+// groups and packages are validated as by NewRuleSetGroup and NewRulePackage. The Coverage of rule set
+// groups is not included.
+//
+//	{
+//	  "language": "sv",
+//	  "ruleSetGroups": [
+//	    {
+//	      "name": "SpelloutRules",
+//	      "language": "sv",
+//	      "ruleSets": [
+//	        {
+//	          "name": "spellout-numbering",
+//	          "rules": [
+//	            {"base": {"string": "-x"}, "subs": [{"orth": "minus "}, {"operation": ">>"}]},
+//	            {"base": {"int": 20, "radix": 10}, "subs": [{"orth": "tjugo"}, {"optional": true, "operation": ">>"}]},
+//	            {"base": {"int": 1000, "radix": 10}, "subs": [{"numericFormat": "#,##0", "language": "sv", "operation": "=="}]},
+//	            ...
+//	          ]
+//	        }
+//	      ]
+//	    }
+//	  ],
+//	  "ruleSetNames": {"ordinal": "spellout-ordinal-masculine"},
+//	  "fallback": {"fallback": "digits", "ruleSet": "spellout-numbering"},
+//	  "ruleSetFallbacks": {"spellout-ordinal": {"fallback": "pass-through"}}
+//	}
+//
+// A sub has at most one of orth, ruleRef, numericFormat and pluralFormat (with language for the formatters),
+// and an optional operation (>>, << or ==). To keep the operations readable in the output, encode with a
+// json.Encoder with SetEscapeHTML(false).
+
+// marshalJSON is json.Marshal without HTML escaping, so that operations such as >> are kept readable
+// when encoded with a json.Encoder with SetEscapeHTML(false)
+func marshalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+type subJSON struct {
+	Optional      bool     `json:"optional,omitempty"`
+	Orth          string   `json:"orth,omitempty"`
+	RuleRef       string   `json:"ruleRef,omitempty"`
+	NumericFormat string   `json:"numericFormat,omitempty"`
+	PluralFormat  string   `json:"pluralFormat,omitempty"`
+	Language      Language `json:"language,omitempty"`
+	Operation     string   `json:"operation,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (sub Sub) MarshalJSON() ([]byte, error) {
+	res := subJSON{Optional: sub.Optional, Orth: sub.Orth, RuleRef: sub.RuleRef, Operation: sub.Operation}
+	if sub.IsNumericFormatter() {
+		res.NumericFormat = sub.NumericFormatter.format
+		res.Language = sub.NumericFormatter.Language()
+	}
+	if sub.IsPluralFormatter() {
+		res.PluralFormat = sub.PluralFormatter.format
+		res.Language = sub.PluralFormatter.Language()
+	}
+	return marshalJSON(res)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Formatters are rebuilt from their patterns.
+func (sub *Sub) UnmarshalJSON(data []byte) error {
+	var in subJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	res := Sub{Optional: in.Optional, Orth: in.Orth, RuleRef: in.RuleRef, Operation: in.Operation}
+	if in.NumericFormat != "" {
+		res.NumericFormatter = NewNumericFormatter(in.Language, in.NumericFormat)
+	}
+	if in.PluralFormat != "" {
+		f, err := NewPluralFormatter(in.Language, in.PluralFormat)
+		if err != nil {
+			return err
+		}
+		res.PluralFormatter = f
+	}
+	if err := res.Validate(); err != nil {
+		return fmt.Errorf("invalid sub %s : %v", data, err)
+	}
+	*sub = res
+	return nil
+}
+
+type baseJSON struct {
+	Int       int    `json:"int,omitempty"`
+	Radix     int    `json:"radix,omitempty"`
+	Decrement int    `json:"decrement,omitempty"`
+	String    string `json:"string,omitempty"`
+}
+
+type baseRuleJSON struct {
+	Base baseJSON `json:"base"`
+	Subs []Sub    `json:"subs"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (r BaseRule) MarshalJSON() ([]byte, error) {
+	return marshalJSON(baseRuleJSON{
+		Base: baseJSON{Int: r.Base.Int, Radix: r.Base.Radix, Decrement: r.Base.Decrement, String: r.Base.String},
+		Subs: r.Subs,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (r *BaseRule) UnmarshalJSON(data []byte) error {
+	var in baseRuleJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if in.Base.Int != 0 && in.Base.String != "" {
+		return fmt.Errorf("rule must use either BaseInt or BaseString, not both: %s", data)
+	}
+	*r = BaseRule{
+		Base: Base{Int: in.Base.Int, Radix: in.Base.Radix, Decrement: in.Base.Decrement, String: in.Base.String},
+		Subs: in.Subs,
+	}
+	return nil
+}
+
+type ruleSetJSON struct {
+	Name    string     `json:"name"`
+	Private bool       `json:"private,omitempty"`
+	Rules   []BaseRule `json:"rules"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (rs RuleSet) MarshalJSON() ([]byte, error) {
+	return marshalJSON(ruleSetJSON{Name: rs.Name, Private: rs.Private, Rules: rs.Rules})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (rs *RuleSet) UnmarshalJSON(data []byte) error {
+	var in ruleSetJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*rs = RuleSet{Name: in.Name, Private: in.Private, Rules: in.Rules}
+	return nil
+}
+
+type ruleSetGroupJSON struct {
+	Name     string    `json:"name"`
+	Language Language  `json:"language"`
+	RuleSets []RuleSet `json:"ruleSets"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (g RuleSetGroup) MarshalJSON() ([]byte, error) {
+	res := ruleSetGroupJSON{Name: g.Name, Language: g.Language, RuleSets: []RuleSet{}}
+	for _, rs := range g.RuleSets {
+		res.RuleSets = append(res.RuleSets, rs)
+	}
+	sort.Slice(res.RuleSets, func(i, j int) bool { return res.RuleSets[i].Name < res.RuleSets[j].Name })
+	return marshalJSON(res)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The group is validated as by NewRuleSetGroup.
+func (g *RuleSetGroup) UnmarshalJSON(data []byte) error {
+	var in ruleSetGroupJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	for _, rs := range in.RuleSets {
+		if rs.Name == "" {
+			return fmt.Errorf("rule set without name in rule set group %s", in.Name)
+		}
+	}
+	res, err := NewRuleSetGroup(in.Name, in.Language, in.RuleSets)
+	if err != nil {
+		return err
+	}
+	if len(res.RuleSets) != len(in.RuleSets) {
+		return fmt.Errorf("duplicate rule set names in rule set group %s", in.Name)
+	}
+	*g = res
+	return nil
+}
+
+type fallbackPolicyJSON struct {
+	Fallback Fallback `json:"fallback,omitempty"`
+	RuleSet  string   `json:"ruleSet,omitempty"`
+}
+
+type rulePackageJSON struct {
+	Language         Language                      `json:"language"`
+	Debug            bool                          `json:"debug,omitempty"`
+	RuleSetGroups    []RuleSetGroup                `json:"ruleSetGroups"`
+	RuleSetNames     map[Kind]string               `json:"ruleSetNames,omitempty"`
+	Fallback         *fallbackPolicyJSON           `json:"fallback,omitempty"`
+	RuleSetFallbacks map[string]fallbackPolicyJSON `json:"ruleSetFallbacks,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (r RulePackage) MarshalJSON() ([]byte, error) {
+	res := rulePackageJSON{
+		Language:      r.Language,
+		Debug:         r.Debug,
+		RuleSetGroups: r.RuleSetGroups,
+		RuleSetNames:  r.RuleSetNames,
+	}
+	if r.Fallback != (FallbackPolicy{}) {
+		res.Fallback = &fallbackPolicyJSON{Fallback: r.Fallback.Fallback, RuleSet: r.Fallback.RuleSet}
+	}
+	if len(r.RuleSetFallbacks) > 0 {
+		res.RuleSetFallbacks = make(map[string]fallbackPolicyJSON)
+		for name, p := range r.RuleSetFallbacks {
+			res.RuleSetFallbacks[name] = fallbackPolicyJSON{Fallback: p.Fallback, RuleSet: p.RuleSet}
+		}
+	}
+	if res.RuleSetGroups == nil {
+		res.RuleSetGroups = []RuleSetGroup{}
+	}
+	return marshalJSON(res)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The package is validated as by NewRulePackage.
+func (r *RulePackage) UnmarshalJSON(data []byte) error {
+	var in rulePackageJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	res, err := NewRulePackage(in.Language, in.RuleSetGroups, in.Debug)
+	if err != nil {
+		return err
+	}
+	res.RuleSetNames = in.RuleSetNames
+	if in.Fallback != nil {
+		res.Fallback = FallbackPolicy{Fallback: in.Fallback.Fallback, RuleSet: in.Fallback.RuleSet}
+	}
+	if in.RuleSetFallbacks != nil {
+		res.RuleSetFallbacks = make(map[string]FallbackPolicy)
+		for name, p := range in.RuleSetFallbacks {
+			res.RuleSetFallbacks[name] = FallbackPolicy{Fallback: p.Fallback, RuleSet: p.RuleSet}
+		}
+	}
+	*r = res
+	return nil
+}
@@ -0,0 +1,132 @@
+package rbnf
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	g := testGroup(t)
+	pkg, err := NewRulePackage(g.Language, []RuleSetGroup{g}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg.RuleSetNames = map[Kind]string{DigitsOrdinal: "digits-ordinal"}
+	pkg.Fallback = FallbackPolicy{Fallback: FallbackDigits, RuleSet: "spellout-numbering"}
+	pkg.RuleSetFallbacks = map[string]FallbackPolicy{"digits-ordinal": {Fallback: FallbackPassThrough}}
+	data, err := json.Marshal(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var res RulePackage
+	if err := json.Unmarshal(data, &res); err != nil {
+		t.Fatal(err)
+	}
+	data2, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(data2) {
+		t.Errorf(fs, string(data), string(data2))
+	}
+
+	for _, input := range []string{"0", "3", "-2", "2.3", "21", "323", "1000", "12345", "x"} {
+		for _, ruleSet := range []string{"spellout-numbering", "digits-ordinal", "hundreds"} {
+			exp, expErr := pkg.Spellout(input, "SpelloutRules", ruleSet, false)
+			got, gotErr := res.Spellout(input, "SpelloutRules", ruleSet, false)
+			if (expErr == nil) != (gotErr == nil) {
+				t.Errorf("%s/%s : expected error %v, got %v", ruleSet, input, expErr, gotErr)
+			} else if exp != got {
+				t.Errorf("%s/%s : "+fs, ruleSet, input, exp, got)
+			}
+		}
+	}
+
+	if exp, got := pkg.Fallback, res.Fallback; exp != got {
+		t.Errorf(fs, exp, got)
+	}
+	if exp, got := pkg.RuleSetFallbacks["digits-ordinal"], res.RuleSetFallbacks["digits-ordinal"]; exp != got {
+		t.Errorf(fs, exp, got)
+	}
+	if exp, got := "digits-ordinal", res.RuleSetNames[DigitsOrdinal]; exp != got {
+		t.Errorf(fs, exp, got)
+	}
+	if !res.RuleSetGroups[0].RuleSets["hundreds"].Private {
+		t.Error("expected rule set hundreds to be private")
+	}
+}
+
+func TestJSONSub(t *testing.T) {
+	lang := Language("en")
+	tests := []struct {
+		sub string
+		exp string
+	}{
+		{"minus ", `{"orth":"minus "}`},
+		{">>", `{"operation":">>"}`},
+		{"[ and >>]", `{"optional":true,"orth":" and >>"}`},
+		{"=%spellout-numbering=", `{"ruleRef":"%spellout-numbering","operation":"=="}`},
+		{"=#,##0=", `{"numericFormat":"#,##0","language":"en","operation":"=="}`},
+		{"$(ordinal,one{st}other{th})$", `{"pluralFormat":"$(ordinal,one{st}other{th})$","language":"en"}`},
+	}
+	for _, test := range tests {
+		sub, err := ParseSub(test.sub, lang)
+		if err != nil {
+			t.Errorf("%s : %v", test.sub, err)
+			continue
+		}
+		data, err := marshalJSON(sub)
+		if err != nil {
+			t.Errorf("%s : %v", test.sub, err)
+			continue
+		}
+		if string(data) != test.exp {
+			t.Errorf(fs, test.exp, string(data))
+		}
+		var res Sub
+		if err := json.Unmarshal(data, &res); err != nil {
+			t.Errorf("%s : %v", test.sub, err)
+		} else if res.String() != sub.String() {
+			t.Errorf(fs, sub.String(), res.String())
+		}
+	}
+}
+
+func TestJSONInvalid(t *testing.T) {
+	tests := []string{
+		`{"language":"en","ruleSetGroups":[{"name":"SpelloutRules","language":"sv","ruleSets":[]}]}`,
+		`{"language":"en","ruleSetGroups":[{"name":"SpelloutRules","language":"en","ruleSets":[{"name":"a","rules":[{"base":{"int":1,"radix":10},"subs":[{"ruleRef":"%b","operation":"=="}]}]}]}]}`,
+		`{"language":"en","ruleSetGroups":[{"name":"SpelloutRules","language":"en","ruleSets":[{"name":"a","rules":[{"base":{"int":1,"string":"x.x"},"subs":[]}]}]}]}`,
+		`{"language":"en","ruleSetGroups":[{"name":"SpelloutRules","language":"en","ruleSets":[{"name":"a","rules":[{"base":{"int":1,"radix":10},"subs":[{"orth":"a","ruleRef":"b"}]}]}]}]}`,
+		`{"language":"en","ruleSetGroups":[{"name":"SpelloutRules","language":"en","ruleSets":[{"name":"a","rules":[{"base":{"int":1,"radix":10},"subs":[{"pluralFormat":"$(dual,one{st}other{th})$","language":"en"}]}]}]}]}`,
+		`{"language":"en","ruleSetGroups":[{"name":"SpelloutRules","language":"en","ruleSets":[{"name":"a","rules":[]},{"name":"a","rules":[]}]}]}`,
+	}
+	for _, test := range tests {
+		var res RulePackage
+		if err := json.Unmarshal([]byte(test), &res); err == nil {
+			t.Errorf("expected error for %s", test)
+		} else if strings.Contains(err.Error(), "unexpected end") {
+			t.Errorf("malformed test JSON %s : %v", test, err)
+		}
+	}
+}
+
+func TestJSONDecrement(t *testing.T) {
+	r := NewIntRule("en", 1100, 100, "<<", " hundred", "[ >>]")
+	r.Base.Decrement = 1
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, res := `"decrement":1`, string(data); !strings.Contains(res, exp) {
+		t.Errorf(fs, exp, res)
+	}
+	var res BaseRule
+	if err := json.Unmarshal(data, &res); err != nil {
+		t.Error(err)
+	} else if res.Base != r.Base {
+		t.Errorf(fs, r.Base, res.Base)
+	}
+}
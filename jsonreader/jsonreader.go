@@ -0,0 +1,174 @@
+// Package jsonreader contains a parser for the RBNF data of the CLDR JSON distribution, cldr-rbnf/rbnf/<lang>.json
+// (https://github.com/unicode-org/cldr-json).
+//
+// License for CLDR: https://github.com/unicode-org/cldr/blob/master/ICU-LICENSE
+package jsonreader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/stts-se/rbnf"
+	"github.com/stts-se/rbnf/icureader"
+)
+
+// cldrJSON is the top level of a cldr-rbnf file:
+//
+//	{
+//	  "rbnf": {
+//	    "identity": {"language": "sv"},
+//	    "rbnf": {
+//	      "SpelloutRules": {
+//	        "%spellout-numbering": [["-x", "minus →→;"], ["0", "noll;"], ...],
+//	        "%%private-name": [...]
+//	      }
+//	    }
+//	  }
+//	}
+type cldrJSON struct {
+	Rbnf struct {
+		Identity struct {
+			Language  string `json:"language"`
+			Script    string `json:"script"`
+			Territory string `json:"territory"`
+		} `json:"identity"`
+		Rbnf json.RawMessage `json:"rbnf"`
+	} `json:"rbnf"`
+}
+
+type member struct {
+	key   string
+	value json.RawMessage
+}
+
+// members returns the members of a JSON object, in order of appearance (rule set groups are kept in file order)
+func members(data json.RawMessage) ([]member, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, fmt.Errorf("expected object, found %s", data)
+	}
+	var res []member
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		res = append(res, member{key: t.(string), value: value})
+	}
+	return res, nil
+}
+
+// convertRuleSet converts a rule set from its rule array: [[value, rule], ...]. The value may include the
+// radix (1100/100); rules without a value get the previous value plus one.
+func convertRuleSet(key string, data json.RawMessage, lang rbnf.Language) (rbnf.RuleSet, error) {
+	res := rbnf.RuleSet{Name: strings.TrimLeft(key, "%"), Private: strings.HasPrefix(key, "%%")}
+	var rules [][]string
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return res, fmt.Errorf("invalid rules for rule set %s : %v", key, err)
+	}
+	next := 0
+	for _, r := range rules {
+		var value, radix, text string
+		switch len(r) {
+		case 1:
+			value, text = fmt.Sprint(next), r[0]
+		case 2:
+			vr := strings.SplitN(r[0], "/", 2)
+			value, text = vr[0], r[1]
+			if len(vr) == 2 {
+				radix = strings.TrimRight(vr[1], ">")
+				value += vr[1][len(radix):]
+			}
+		default:
+			return res, fmt.Errorf("invalid rule for rule set %s : %v", key, r)
+		}
+		rule, err := icureader.ParseRule(value, radix, text, lang)
+		if err == icureader.ErrUnsupportedRule {
+			continue
+		}
+		if err != nil {
+			return res, fmt.Errorf("parse failed for rule %v in rule set %s : %v", r, key, err)
+		}
+		if rule.Base.IsInt() {
+			next = rule.Base.Int + 1
+		}
+		res.Rules = append(res.Rules, rule)
+	}
+	return res, nil
+}
+
+// RulesFromJSONReader reads a rule package from cldr-rbnf JSON data. Rule set groups may also be given as
+// rule text in ICU rule syntax ("SpelloutRules": "%spellout-numbering: ...").
+func RulesFromJSONReader(r io.Reader) (rbnf.RulePackage, error) {
+	var data cldrJSON
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return rbnf.RulePackage{}, fmt.Errorf("RulesFromJSONReader: failed to process JSON : %v", err)
+	}
+	id := data.Rbnf.Identity
+	if id.Language == "" {
+		return rbnf.RulePackage{}, fmt.Errorf("RulesFromJSONReader: no language in identity")
+	}
+	locale := []string{id.Language}
+	for _, s := range []string{id.Script, id.Territory} {
+		if s != "" {
+			locale = append(locale, s)
+		}
+	}
+	lang := rbnf.Language(strings.Join(locale, "_"))
+
+	groups, err := members(data.Rbnf.Rbnf)
+	if err != nil {
+		return rbnf.RulePackage{}, fmt.Errorf("RulesFromJSONReader: invalid rule set groups : %v", err)
+	}
+	if len(groups) == 0 {
+		return rbnf.RulePackage{}, fmt.Errorf("RulesFromJSONReader: no rule set groups")
+	}
+	var res []rbnf.RuleSetGroup
+	for _, g := range groups {
+		var ruleSets []rbnf.RuleSet
+		var text string
+		if err := json.Unmarshal(g.value, &text); err == nil {
+			if ruleSets, err = icureader.ParseRuleSets(text, lang); err != nil {
+				return rbnf.RulePackage{}, fmt.Errorf("RulesFromJSONReader: failed to parse rules of rule set group %s : %v", g.key, err)
+			}
+		} else {
+			sets, err := members(g.value)
+			if err != nil {
+				return rbnf.RulePackage{}, fmt.Errorf("RulesFromJSONReader: invalid rule sets for rule set group %s : %v", g.key, err)
+			}
+			for _, rs := range sets {
+				ruleSet, err := convertRuleSet(rs.key, rs.value, lang)
+				if err != nil {
+					return rbnf.RulePackage{}, fmt.Errorf("RulesFromJSONReader: %v", err)
+				}
+				if len(ruleSet.Rules) > 0 {
+					ruleSets = append(ruleSets, ruleSet)
+				}
+			}
+		}
+		group, err := rbnf.NewRuleSetGroup(g.key, lang, ruleSets)
+		if err != nil {
+			return rbnf.RulePackage{}, fmt.Errorf("RulesFromJSONReader: %v", err)
+		}
+		res = append(res, group)
+	}
+	return rbnf.NewRulePackage(lang, res, false)
+}
+
+// RulesFromJSONFile reads a rule package from a cldr-rbnf JSON file (see RulesFromJSONReader)
+func RulesFromJSONFile(fn string) (rbnf.RulePackage, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return rbnf.RulePackage{}, fmt.Errorf("RulesFromJSONFile: failed to read JSON file : %v", err)
+	}
+	defer f.Close()
+	return RulesFromJSONReader(f)
+}
@@ -0,0 +1,118 @@
+package jsonreader
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stts-se/rbnf"
+	"github.com/stts-se/rbnf/xmlreader"
+)
+
+var fs = "Expected '%v', got '%v'"
+
+var testInputs = []string{"0", "1", "2", "7", "11", "21", "99", "100", "101", "999", "1000", "1999", "2021", "12345", "1000000", "-3", "3.14"}
+
+func TestRulesFromJSONFile(t *testing.T) {
+	files, err := filepath.Glob("test_data/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no test files")
+	}
+	for _, fn := range files {
+		lang := strings.TrimSuffix(filepath.Base(fn), ".json")
+		xmlPack, err := xmlreader.RulesFromXMLFile(filepath.Join("..", "xmlreader", "test_data", lang+".xml"))
+		if err != nil {
+			t.Fatalf("%s: %v", lang, err)
+		}
+		jsonPack, err := RulesFromJSONFile(fn)
+		if err != nil {
+			t.Fatalf("%s: %v", lang, err)
+		}
+		if exp, res := xmlPack.Language, jsonPack.Language; res != exp {
+			t.Errorf(fs, exp, res)
+		}
+		if changes := rbnf.Diff(xmlPack, jsonPack); len(changes) > 0 {
+			t.Errorf("%s: %d changes between xml and json, first: %v", lang, len(changes), changes[0])
+		}
+
+		for _, g := range xmlPack.RuleSetGroups {
+			for _, rs := range g.RuleSets {
+				if rs.Private {
+					continue
+				}
+				for _, input := range testInputs {
+					exp, expErr := xmlPack.Spellout(input, g.Name, rs.Name, false)
+					res, resErr := jsonPack.Spellout(input, g.Name, rs.Name, false)
+					if (expErr == nil) != (resErr == nil) || res != exp {
+						t.Errorf("%s %s %s: expected '%v' (%v), got '%v' (%v)", lang, rs.Name, input, exp, expErr, res, resErr)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestRulesFromJSONReader(t *testing.T) {
+	input := `{"rbnf": {"identity": {"language": "sv", "territory": "FI"}, "rbnf": {
+  "SpelloutRules": {
+    "%%private": [["0", "noll;"], ["ett;"], ["två;"]],
+    "%public": [["-x", "minus →→;"], ["0", "=%%private=;"], ["100/10", "←← hundra[ →→];"]]
+  },
+  "OrdinalRules": "%digits-ordinal: =#,##0=:e;"
+}}}`
+	pack, err := RulesFromJSONReader(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, res := rbnf.Language("sv_FI"), pack.Language; res != exp {
+		t.Errorf(fs, exp, res)
+	}
+	if exp, res := 2, len(pack.RuleSetGroups); res != exp {
+		t.Fatalf(fs, exp, res)
+	}
+	if exp, res := "SpelloutRules", pack.RuleSetGroups[0].Name; res != exp {
+		t.Errorf(fs, exp, res)
+	}
+	if !pack.RuleSetGroups[0].RuleSets["private"].Private {
+		t.Errorf("expected rule set private to be private")
+	}
+	if exp, res := 100, pack.RuleSetGroups[0].RuleSets["public"].Rules[2].Base.Int; res != exp {
+		t.Errorf(fs, exp, res)
+	}
+	if exp, res := 10, pack.RuleSetGroups[0].RuleSets["public"].Rules[2].Base.Radix; res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	for _, test := range []struct{ input, exp string }{
+		{"2", "två"},
+		{"-1", "minus ett"},
+		{"201", "två hundra ett"},
+	} {
+		res, err := pack.Spellout(test.input, "SpelloutRules", "public", false)
+		if err != nil {
+			t.Error(err)
+		} else if res != test.exp {
+			t.Errorf(fs, test.exp, res)
+		}
+	}
+	if res, err := pack.Spellout("3", "OrdinalRules", "digits-ordinal", false); err != nil {
+		t.Error(err)
+	} else if exp := "3:e"; res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	for _, input := range []string{
+		`{"rbnf": {"identity": {}, "rbnf": {"SpelloutRules": {}}}}`,
+		`{"rbnf": {"identity": {"language": "sv"}, "rbnf": {}}}`,
+		`{"rbnf": {"identity": {"language": "sv"}, "rbnf": {"SpelloutRules": {"%x": [["1", "a", "b"]]}}}}`,
+		`{"rbnf": {"identity": {"language": "sv"}, "rbnf": {"SpelloutRules": [1]}}}`,
+		`{"rbnf"`,
+	} {
+		if _, err := RulesFromJSONReader(strings.NewReader(input)); err == nil {
+			t.Errorf("expected error for %s", input)
+		}
+	}
+}
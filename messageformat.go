@@ -0,0 +1,475 @@
+package rbnf
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// MessageFormat is a parsed message in ICU MessageFormat syntax (https://unicode-org.github.io/icu/userguide/format_parse/messages/),
+// formatted using the rule package. Supported argument types are:
+//
+//	{n}                             the value (numbers formatted for the package language)
+//	{n, number} {n, number, integer} number formatted for the package language
+//	{n, spellout}                   spelled out using the cardinal rule set (see RuleSetFor)
+//	{n, spellout, %rule-set-name}   spelled out using the named rule set
+//	{n, ordinal}                    ordinal number in digits, using the digits-ordinal rule set
+//	{n, ordinal, %rule-set-name}    formatted using the named rule set
+//	{n, plural, [offset:k] =0 {...} one {...} other {...}}
+//	{n, selectordinal, one {...} other {...}}
+//	{s, select, female {...} other {...}}
+//
+// In plural and selectordinal messages, # is replaced by the number (minus the offset) formatted as a
+// number. Plural cases are selected using the CLDR plural rules for the package language, as for plural
+// formatters in the rules. Explicit values (=1, =1.5) are compared numerically with the number, before
+// the offset is subtracted. Apostrophes quote syntax characters as in ICU ('{' for a literal {, and two apostrophes for a literal apostrophe).
+type MessageFormat struct {
+	pkg     *RulePackage
+	pattern string
+	nodes   []msgNode
+}
+
+type msgNode interface {
+	format(m *MessageFormat, args map[string]interface{}, pluralNumber string, res *strings.Builder) error
+}
+
+// msgText is literal text
+type msgText string
+
+// msgPound is # in plural messages
+type msgPound struct{}
+
+// msgArg is a simple argument: {name}, {name, type} or {name, type, style}
+type msgArg struct {
+	name  string
+	typ   string
+	style string
+}
+
+// msgSelect is a plural, selectordinal or select argument
+type msgSelect struct {
+	name   string
+	typ    string
+	offset int
+	cases  []msgCase
+}
+
+type msgCase struct {
+	key   string
+	nodes []msgNode
+}
+
+// NewMessageFormat parses an ICU MessageFormat pattern. Rule set names used in spellout and ordinal
+// arguments are validated against the package.
+func (r *RulePackage) NewMessageFormat(pattern string) (*MessageFormat, error) {
+	p := &msgParser{pkg: r, s: []rune(pattern)}
+	nodes, err := p.parseMessage(false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message format '%s' : %v", pattern, err)
+	}
+	if p.pos < len(p.s) {
+		return nil, fmt.Errorf("invalid message format '%s' : unmatched } at position %d", pattern, p.pos)
+	}
+	return &MessageFormat{pkg: r, pattern: pattern, nodes: nodes}, nil
+}
+
+// FormatMessage parses the ICU MessageFormat pattern and formats it using the named arguments
+func (r *RulePackage) FormatMessage(pattern string, args map[string]interface{}) (string, error) {
+	m, err := r.NewMessageFormat(pattern)
+	if err != nil {
+		return "", err
+	}
+	return m.Format(args)
+}
+
+func (m *MessageFormat) String() string {
+	return m.pattern
+}
+
+// Format formats the message using the named arguments
+func (m *MessageFormat) Format(args map[string]interface{}) (string, error) {
+	var res strings.Builder
+	if err := formatNodes(m, m.nodes, args, "", &res); err != nil {
+		return "", err
+	}
+	return res.String(), nil
+}
+
+// FormatArgs formats the message using numbered arguments ({0}, {1}, ...)
+func (m *MessageFormat) FormatArgs(args ...interface{}) (string, error) {
+	named := make(map[string]interface{})
+	for i, arg := range args {
+		named[strconv.Itoa(i)] = arg
+	}
+	return m.Format(named)
+}
+
+func formatNodes(m *MessageFormat, nodes []msgNode, args map[string]interface{}, pluralNumber string, res *strings.Builder) error {
+	for _, n := range nodes {
+		if err := n.format(m, args, pluralNumber, res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MessageFormat) languageTag() language.Tag {
+	return m.pkg.Language.Tag()
+}
+
+func (m *MessageFormat) printer() *message.Printer {
+	return message.NewPrinter(m.languageTag())
+}
+
+// formatNumber formats a number in the canonical rule input format for the package language
+func (m *MessageFormat) formatNumber(input string) string {
+	if n, err := strconv.ParseInt(input, 10, 64); err == nil {
+		return m.printer().Sprint(n)
+	}
+	if f, err := strconv.ParseFloat(input, 64); err == nil {
+		return m.printer().Sprint(f)
+	}
+	return input
+}
+
+func (t msgText) format(m *MessageFormat, args map[string]interface{}, pluralNumber string, res *strings.Builder) error {
+	res.WriteString(string(t))
+	return nil
+}
+
+func (msgPound) format(m *MessageFormat, args map[string]interface{}, pluralNumber string, res *strings.Builder) error {
+	res.WriteString(m.formatNumber(pluralNumber))
+	return nil
+}
+
+func lookupArg(args map[string]interface{}, name string) (interface{}, error) {
+	v, ok := args[name]
+	if !ok {
+		return nil, fmt.Errorf("missing message argument: %s", name)
+	}
+	return v, nil
+}
+
+func (a msgArg) format(m *MessageFormat, args map[string]interface{}, pluralNumber string, res *strings.Builder) error {
+	v, err := lookupArg(args, a.name)
+	if err != nil {
+		return err
+	}
+	if s, ok := v.(string); ok && a.typ == "" {
+		res.WriteString(s)
+		return nil
+	}
+	n := m.pkg.Number(v)
+	var s string
+	switch a.typ {
+	case "", "number":
+		input, err := n.input()
+		if err != nil {
+			return fmt.Errorf("invalid message argument %s : %v", a.name, err)
+		}
+		if a.style == "integer" {
+			if input, err = ApplyPrecision(input, MaxFractionDigits(0)); err != nil {
+				return err
+			}
+		}
+		s = m.formatNumber(input)
+	case "spellout", "ordinal":
+		if a.style != "" {
+			n = n.RuleSet(strings.TrimPrefix(a.style, "%"))
+		} else if a.typ == "ordinal" {
+			n = n.Kind(DigitsOrdinal)
+		}
+		if s, err = n.Spellout(); err != nil {
+			return fmt.Errorf("couldn't format message argument %s : %v", a.name, err)
+		}
+	}
+	res.WriteString(s)
+	return nil
+}
+
+func (sel msgSelect) format(m *MessageFormat, args map[string]interface{}, pluralNumber string, res *strings.Builder) error {
+	value, err := lookupArg(args, sel.name)
+	if err != nil {
+		return err
+	}
+	if sel.typ == "select" {
+		key := fmt.Sprint(value)
+		for _, c := range sel.cases {
+			if c.key == key {
+				return formatNodes(m, c.nodes, args, pluralNumber, res)
+			}
+		}
+		return formatNodes(m, sel.caseNodes("other"), args, pluralNumber, res)
+	}
+
+	input, err := m.pkg.Number(value).input()
+	if err != nil {
+		return fmt.Errorf("invalid message argument %s : %v", sel.name, err)
+	}
+	number := input
+	if sel.offset != 0 {
+		x, ok := new(big.Rat).SetString(input)
+		if !ok {
+			return fmt.Errorf("invalid message argument %s : %s", sel.name, input)
+		}
+		if number, err = canonicalBigRat(x.Sub(x, big.NewRat(int64(sel.offset), 1)), -1); err != nil {
+			return err
+		}
+	}
+	// explicit values match the number itself (compared numerically, so =1 matches 1.0), plural forms the number minus the offset
+	if x, ok := new(big.Rat).SetString(input); ok {
+		for _, c := range sel.cases {
+			if !strings.HasPrefix(c.key, "=") {
+				continue
+			}
+			if v, ok := new(big.Rat).SetString(strings.TrimPrefix(c.key, "=")); ok && v.Cmp(x) == 0 {
+				return formatNodes(m, c.nodes, args, number, res)
+			}
+		}
+	}
+	rules := plural.Cardinal
+	if sel.typ == "selectordinal" {
+		rules = plural.Ordinal
+	}
+	i, v, w, f, t, _, err := pluralOperands(number)
+	if err != nil {
+		return fmt.Errorf("invalid message argument %s : %v", sel.name, err)
+	}
+	form := rules.MatchPlural(m.languageTag(), i, v, w, f, t)
+	for _, c := range sel.cases {
+		if pf, ok := pluralForms[c.key]; ok && pf == form {
+			return formatNodes(m, c.nodes, args, number, res)
+		}
+	}
+	return formatNodes(m, sel.caseNodes("other"), args, number, res)
+}
+
+func (sel msgSelect) caseNodes(key string) []msgNode {
+	for _, c := range sel.cases {
+		if c.key == key {
+			return c.nodes
+		}
+	}
+	return nil
+}
+
+type msgParser struct {
+	pkg *RulePackage
+	s   []rune
+	pos int
+}
+
+func (p *msgParser) peek() (rune, bool) {
+	if p.pos >= len(p.s) {
+		return 0, false
+	}
+	return p.s[p.pos], true
+}
+
+func (p *msgParser) skipSpace() {
+	for p.pos < len(p.s) && unicode.IsSpace(p.s[p.pos]) {
+		p.pos++
+	}
+}
+
+// parseMessage parses message text up to an unmatched } or the end of the pattern
+func (p *msgParser) parseMessage(inPlural bool) ([]msgNode, error) {
+	nodes := []msgNode{}
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, msgText(text.String()))
+			text.Reset()
+		}
+	}
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case c == '\'':
+			p.pos++
+			next, ok := p.peek()
+			switch {
+			case ok && next == '\'':
+				text.WriteRune('\'')
+				p.pos++
+			case ok && (next == '{' || next == '}' || next == '|' || (inPlural && next == '#')):
+				// quoted literal text, up to the next single apostrophe
+				for p.pos < len(p.s) {
+					if p.s[p.pos] == '\'' {
+						if p.pos+1 < len(p.s) && p.s[p.pos+1] == '\'' {
+							text.WriteRune('\'')
+							p.pos += 2
+							continue
+						}
+						p.pos++
+						break
+					}
+					text.WriteRune(p.s[p.pos])
+					p.pos++
+				}
+			default:
+				text.WriteRune('\'')
+			}
+		case c == '{':
+			flush()
+			p.pos++
+			node, err := p.parseArgument(inPlural)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, node)
+		case c == '}':
+			flush()
+			return nodes, nil
+		case c == '#' && inPlural:
+			flush()
+			nodes = append(nodes, msgPound{})
+			p.pos++
+		default:
+			text.WriteRune(c)
+			p.pos++
+		}
+	}
+	flush()
+	return nodes, nil
+}
+
+// parseWord parses an argument name, type or selector key
+func (p *msgParser) parseWord() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if unicode.IsSpace(c) || c == ',' || c == '{' || c == '}' {
+			break
+		}
+		p.pos++
+	}
+	return string(p.s[start:p.pos])
+}
+
+func (p *msgParser) expect(c rune) error {
+	p.skipSpace()
+	if next, ok := p.peek(); !ok || next != c {
+		return fmt.Errorf("expected %c at position %d", c, p.pos)
+	}
+	p.pos++
+	return nil
+}
+
+// parseArgument parses an argument following {, up to and including the closing }
+func (p *msgParser) parseArgument(inPlural bool) (msgNode, error) {
+	name := p.parseWord()
+	if name == "" {
+		return nil, fmt.Errorf("missing argument name at position %d", p.pos)
+	}
+	p.skipSpace()
+	if next, ok := p.peek(); ok && next == '}' {
+		p.pos++
+		return msgArg{name: name}, nil
+	}
+	if err := p.expect(','); err != nil {
+		return nil, err
+	}
+	typ := p.parseWord()
+	switch typ {
+	case "plural", "selectordinal", "select":
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+		return p.parseSelect(name, typ, inPlural || typ != "select")
+	case "number", "spellout", "ordinal":
+	default:
+		return nil, fmt.Errorf("unsupported argument type '%s' for argument %s", typ, name)
+	}
+	arg := msgArg{name: name, typ: typ}
+	p.skipSpace()
+	if next, ok := p.peek(); ok && next == ',' {
+		p.pos++
+		start := p.pos
+		for p.pos < len(p.s) && p.s[p.pos] != '}' {
+			p.pos++
+		}
+		arg.style = strings.TrimSpace(string(p.s[start:p.pos]))
+	}
+	if err := p.expect('}'); err != nil {
+		return nil, err
+	}
+	switch {
+	case typ == "number" && arg.style != "" && arg.style != "integer":
+		return nil, fmt.Errorf("unsupported number style '%s' for argument %s", arg.style, name)
+	case typ != "number" && arg.style != "":
+		if !strings.HasPrefix(arg.style, "%") {
+			return nil, fmt.Errorf("invalid rule set name '%s' for argument %s (expected %%name)", arg.style, name)
+		}
+		if _, ok := p.pkg.findGroupWithRuleSet(strings.TrimPrefix(arg.style, "%")); !ok {
+			return nil, fmt.Errorf("no such rule set: %s", arg.style)
+		}
+	}
+	return arg, nil
+}
+
+// parseSelect parses the cases of a plural, selectordinal or select argument, up to and including the closing }.
+// In plural messages, and in select messages nested in them, # is replaced by the number.
+func (p *msgParser) parseSelect(name string, typ string, inPlural bool) (msgNode, error) {
+	sel := msgSelect{name: name, typ: typ}
+	for {
+		p.skipSpace()
+		next, ok := p.peek()
+		if !ok {
+			return nil, fmt.Errorf("unterminated %s argument %s", typ, name)
+		}
+		if next == '}' {
+			p.pos++
+			break
+		}
+		key := p.parseWord()
+		switch {
+		case key == "":
+			return nil, fmt.Errorf("missing selector at position %d", p.pos)
+		case typ != "select" && strings.HasPrefix(key, "offset:") && len(sel.cases) == 0:
+			// the offset value may follow after whitespace, as in ICU (offset: 1)
+			value := strings.TrimPrefix(key, "offset:")
+			if value == "" {
+				value = p.parseWord()
+				key += " " + value
+			}
+			offset, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid offset '%s' for argument %s", key, name)
+			}
+			sel.offset = offset
+			continue
+		case typ != "select" && strings.HasPrefix(key, "="):
+			// explicit values may be decimal numbers, as in ICU
+			if _, err := ApplyPrecision(strings.TrimPrefix(key, "=")); err != nil {
+				return nil, fmt.Errorf("invalid %s selector '%s' for argument %s", typ, key, name)
+			}
+		case typ != "select":
+			if err := validatePluralSelector(key); err != nil || strings.HasPrefix(key, "<") {
+				return nil, fmt.Errorf("invalid %s selector '%s' for argument %s", typ, key, name)
+			}
+		}
+		if err := p.expect('{'); err != nil {
+			return nil, err
+		}
+		nodes, err := p.parseMessage(inPlural)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect('}'); err != nil {
+			return nil, err
+		}
+		sel.cases = append(sel.cases, msgCase{key: key, nodes: nodes})
+	}
+	if sel.caseNodes("other") == nil {
+		return nil, fmt.Errorf("missing 'other' case for %s argument %s", typ, name)
+	}
+	return sel, nil
+}
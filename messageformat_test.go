@@ -0,0 +1,88 @@
+package rbnf_test
+
+import (
+	"testing"
+)
+
+func TestMessageFormat(t *testing.T) {
+	pkg := testPackage(t, "en")
+
+	tests := []struct {
+		pattern string
+		args    map[string]interface{}
+		exp     string
+	}{
+		{"You have {n} items", map[string]interface{}{"n": 1234}, "You have 1,234 items"},
+		{"{n, number, integer}", map[string]interface{}{"n": 2.7}, "3"},
+		{"{name} has {n, spellout} cats", map[string]interface{}{"name": "Kim", "n": 3}, "Kim has three cats"},
+		{"the {n, spellout, %spellout-ordinal} time", map[string]interface{}{"n": 2}, "the second time"},
+		{"{n, plural, =0 {no cats} one {one cat} other {{n, spellout} cats}}", map[string]interface{}{"n": 0}, "no cats"},
+		{"{n, plural, =0 {no cats} one {one cat} other {{n, spellout} cats}}", map[string]interface{}{"n": 1}, "one cat"},
+		{"{n, plural, =0 {no cats} one {one cat} other {{n, spellout} cats}}", map[string]interface{}{"n": 4}, "four cats"},
+		// explicit values are compared numerically
+		{"{n, plural, =1 {exactly one} other {{n, spellout}}}", map[string]interface{}{"n": "1.0"}, "exactly one"},
+		{"{n, plural, =1.0 {exactly one} other {{n, spellout}}}", map[string]interface{}{"n": 1}, "exactly one"},
+		{"{n, plural, =01 {exactly one} other {{n, spellout}}}", map[string]interface{}{"n": 1}, "exactly one"},
+		{"{n, plural, =1.5 {one and a half} other {{n, spellout}}}", map[string]interface{}{"n": "1.50"}, "one and a half"},
+		{"{n, plural, =1.5 {one and a half} other {{n, spellout}}}", map[string]interface{}{"n": 1.5}, "one and a half"},
+		{"{n, plural, one {# cat} other {# cats}}", map[string]interface{}{"n": 1.5}, "1.5 cats"},
+		{"{n, plural, offset:1 =0 {nobody} =1 {{who}} one {{who} and # other} other {{who} and # others}}", map[string]interface{}{"n": 2, "who": "Kim"}, "Kim and 1 other"},
+		{"{n, plural, offset:1 =0 {nobody} =1 {{who}} one {{who} and # other} other {{who} and # others}}", map[string]interface{}{"n": 3, "who": "Kim"}, "Kim and 2 others"},
+		{"{n, plural, offset: 1 =0 {nobody} =1 {{who}} one {{who} and # other} other {{who} and # others}}", map[string]interface{}{"n": 3, "who": "Kim"}, "Kim and 2 others"},
+		{"{n, plural, offset:\t2 other {# more}}", map[string]interface{}{"n": 3}, "1 more"},
+		{"{n, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}", map[string]interface{}{"n": 23}, "23rd"},
+		{"{g, select, female {she} male {he} other {they}} came {n, spellout, %spellout-ordinal}", map[string]interface{}{"g": "female", "n": 1}, "she came first"},
+		{"{g, select, female {she} other {they}}", map[string]interface{}{"g": "x"}, "they"},
+		{"{g, select, female {#{n, plural, one {#} other {many}}} other {they}}", map[string]interface{}{"g": "female", "n": 1}, "#1"},
+		{"it''s '{n}' and '{n, spellout}'", map[string]interface{}{"n": 1}, "it's {n} and {n, spellout}"},
+		{"{n, plural, other {'#' #}}", map[string]interface{}{"n": 5}, "# 5"},
+	}
+	for _, test := range tests {
+		res, err := pkg.FormatMessage(test.pattern, test.args)
+		if err != nil {
+			t.Error(err)
+		} else if res != test.exp {
+			t.Errorf(fs, test.exp, res)
+		}
+	}
+
+	m, err := pkg.NewMessageFormat("{0, spellout} and {1, spellout, %spellout-ordinal}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := m.FormatArgs(2, 3); err != nil {
+		t.Error(err)
+	} else if exp := "two and third"; res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	for _, pattern := range []string{
+		"{n",
+		"{n}}",
+		"{n, date}",
+		"{n, number, currency}",
+		"{n, spellout, spellout-ordinal}",
+		"{n, spellout, %no-such-rule-set}",
+		"{n, plural, one {cat}}",
+		"{n, plural, some {cat} other {cats}}",
+		"{n, plural, offset:x other {cats}}",
+		"{n, plural, offset: x other {cats}}",
+		"{n, plural, offset: {cats}}",
+		"{n, select, a {x} b {y}}",
+		"{n, plural, other {cats}",
+	} {
+		if _, err := pkg.NewMessageFormat(pattern); err == nil {
+			t.Errorf("expected error for %s", pattern)
+		}
+	}
+
+	for _, args := range []map[string]interface{}{
+		{},
+		{"n": true},
+		{"n": "x"},
+	} {
+		if res, err := pkg.FormatMessage("{n, spellout, %spellout-ordinal}", args); err == nil {
+			t.Errorf("expected error for %v, got %s", args, res)
+		}
+	}
+}
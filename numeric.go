@@ -0,0 +1,173 @@
+package rbnf
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// The typed Spellout functions convert a number into the canonical input string expected by the
+// rule matcher: ASCII digits, an optional leading '-', and an optional '.' followed by the fraction
+// digits. Trailing zeros in the fraction are dropped, and negative zero is spelled out as zero.
+//
+// For functions taking a maxFractionDigits argument, a negative value means no limit (shortest
+// round-trip conversion for floats, exact conversion for big numbers).
+
+// canonicalDecimal normalises a plain decimal string (as produced by strconv or math/big) into the
+// canonical rule input format
+func canonicalDecimal(s string) (string, error) {
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	intPart, fracPart := s, ""
+	if pts := strings.SplitN(s, ".", 2); len(pts) == 2 {
+		intPart, fracPart = pts[0], pts[1]
+	}
+	if strings.Trim(intPart+fracPart, "0123456789") != "" || intPart+fracPart == "" {
+		return "", fmt.Errorf("invalid decimal number: %s", s)
+	}
+	intPart = strings.TrimLeft(intPart, "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+	fracPart = strings.TrimRight(fracPart, "0")
+	res := intPart
+	if fracPart != "" {
+		res = res + "." + fracPart
+	}
+	if neg && res != "0" {
+		res = "-" + res
+	}
+	return res, nil
+}
+
+func canonicalInt64(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
+
+func canonicalUint64(n uint64) string {
+	return strconv.FormatUint(n, 10)
+}
+
+func canonicalFloat(f float64, maxFractionDigits int) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("cannot spell out %v", f)
+	}
+	return canonicalDecimal(strconv.FormatFloat(f, 'f', maxFractionDigits, 64))
+}
+
+func canonicalBigInt(n *big.Int) (string, error) {
+	if n == nil {
+		return "", fmt.Errorf("cannot spell out nil *big.Int")
+	}
+	return n.String(), nil
+}
+
+func canonicalBigRat(r *big.Rat, maxFractionDigits int) (string, error) {
+	if r == nil {
+		return "", fmt.Errorf("cannot spell out nil *big.Rat")
+	}
+	if maxFractionDigits < 0 {
+		// exact conversion is only possible if the denominator has no prime factors other than 2 and 5
+		d := new(big.Int).Set(r.Denom())
+		twos, fives := 0, 0
+		two, five, mod := big.NewInt(2), big.NewInt(5), new(big.Int)
+		for d.Cmp(big.NewInt(1)) != 0 {
+			if mod.Mod(d, two).Sign() == 0 {
+				d.Quo(d, two)
+				twos++
+			} else if mod.Mod(d, five).Sign() == 0 {
+				d.Quo(d, five)
+				fives++
+			} else {
+				return "", fmt.Errorf("%s has no finite decimal representation (use maxFractionDigits to round it)", r.String())
+			}
+		}
+		maxFractionDigits = twos
+		if fives > twos {
+			maxFractionDigits = fives
+		}
+	}
+	return canonicalDecimal(r.FloatString(maxFractionDigits))
+}
+
+func canonicalBigFloat(f *big.Float, maxFractionDigits int) (string, error) {
+	if f == nil {
+		return "", fmt.Errorf("cannot spell out nil *big.Float")
+	}
+	if f.IsInf() {
+		return "", fmt.Errorf("cannot spell out %v", f)
+	}
+	return canonicalDecimal(f.Text('f', maxFractionDigits))
+}
+
+// canonicalBig converts a *big.Int, *big.Rat or *big.Float into the canonical rule input format
+func canonicalBig(x interface{}, maxFractionDigits int) (string, error) {
+	switch n := x.(type) {
+	case *big.Int:
+		return canonicalBigInt(n)
+	case *big.Rat:
+		return canonicalBigRat(n, maxFractionDigits)
+	case *big.Float:
+		return canonicalBigFloat(n, maxFractionDigits)
+	default:
+		return "", fmt.Errorf("unsupported type for big number spellout: %T", x)
+	}
+}
+
+// SpelloutInt64 spells out n using the named rule set group and rule set
+func (r *RulePackage) SpelloutInt64(n int64, groupName string, ruleSetName string, debug bool) (string, error) {
+	return r.Spellout(canonicalInt64(n), groupName, ruleSetName, debug)
+}
+
+// SpelloutUint64 spells out n using the named rule set group and rule set
+func (r *RulePackage) SpelloutUint64(n uint64, groupName string, ruleSetName string, debug bool) (string, error) {
+	return r.Spellout(canonicalUint64(n), groupName, ruleSetName, debug)
+}
+
+// SpelloutFloat spells out f using the named rule set group and rule set, with at most maxFractionDigits fraction digits
+func (r *RulePackage) SpelloutFloat(f float64, maxFractionDigits int, groupName string, ruleSetName string, debug bool) (string, error) {
+	input, err := canonicalFloat(f, maxFractionDigits)
+	if err != nil {
+		return "", err
+	}
+	return r.Spellout(input, groupName, ruleSetName, debug)
+}
+
+// SpelloutBig spells out x (a *big.Int, *big.Rat or *big.Float) using the named rule set group and rule set, with at most maxFractionDigits fraction digits
+func (r *RulePackage) SpelloutBig(x interface{}, maxFractionDigits int, groupName string, ruleSetName string, debug bool) (string, error) {
+	input, err := canonicalBig(x, maxFractionDigits)
+	if err != nil {
+		return "", err
+	}
+	return r.Spellout(input, groupName, ruleSetName, debug)
+}
+
+// SpelloutInt64 spells out n using the named rule set
+func (g *RuleSetGroup) SpelloutInt64(n int64, ruleSetName string, debug bool) (string, error) {
+	return g.Spellout(canonicalInt64(n), ruleSetName, debug)
+}
+
+// SpelloutUint64 spells out n using the named rule set
+func (g *RuleSetGroup) SpelloutUint64(n uint64, ruleSetName string, debug bool) (string, error) {
+	return g.Spellout(canonicalUint64(n), ruleSetName, debug)
+}
+
+// SpelloutFloat spells out f using the named rule set, with at most maxFractionDigits fraction digits
+func (g *RuleSetGroup) SpelloutFloat(f float64, maxFractionDigits int, ruleSetName string, debug bool) (string, error) {
+	input, err := canonicalFloat(f, maxFractionDigits)
+	if err != nil {
+		return "", err
+	}
+	return g.Spellout(input, ruleSetName, debug)
+}
+
+// SpelloutBig spells out x (a *big.Int, *big.Rat or *big.Float) using the named rule set, with at most maxFractionDigits fraction digits
+func (g *RuleSetGroup) SpelloutBig(x interface{}, maxFractionDigits int, ruleSetName string, debug bool) (string, error) {
+	input, err := canonicalBig(x, maxFractionDigits)
+	if err != nil {
+		return "", err
+	}
+	return g.Spellout(input, ruleSetName, debug)
+}
@@ -0,0 +1,188 @@
+package rbnf
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestCanonicalFloat(t *testing.T) {
+	// variables, to avoid exact constant arithmetic
+	a, b := 0.1, 0.2
+	tests := []struct {
+		input             float64
+		maxFractionDigits int
+		exp               string
+	}{
+		{1e6, -1, "1000000"},
+		{math.Copysign(0, -1), -1, "0"},
+		{a + b, -1, "0.30000000000000004"},
+		{a + b, 2, "0.3"},
+		{2.50, -1, "2.5"},
+		{-3.14159, 3, "-3.142"},
+		{-0.0001, 2, "0"},
+		{12.0, 4, "12"},
+	}
+	for _, test := range tests {
+		res, err := canonicalFloat(test.input, test.maxFractionDigits)
+		if err != nil {
+			t.Error(err)
+		} else if res != test.exp {
+			t.Errorf(fs, test.exp, res)
+		}
+	}
+
+	if _, err := canonicalFloat(math.NaN(), -1); err == nil {
+		t.Error("expected error here")
+	}
+	if _, err := canonicalFloat(math.Inf(1), -1); err == nil {
+		t.Error("expected error here")
+	}
+}
+
+func TestCanonicalBig(t *testing.T) {
+	var res, exp string
+	var err error
+
+	n, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	res, err = canonicalBig(n, -1)
+	exp = "-123456789012345678901234567890"
+	if err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	res, err = canonicalBig(big.NewRat(3, 8), -1)
+	exp = "0.375"
+	if err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	res, err = canonicalBig(big.NewRat(-10, 4), -1)
+	exp = "-2.5"
+	if err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	_, err = canonicalBig(big.NewRat(1, 3), -1)
+	if err == nil {
+		t.Error("expected error here")
+	}
+
+	res, err = canonicalBig(big.NewRat(1, 3), 2)
+	exp = "0.33"
+	if err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	res, err = canonicalBig(big.NewFloat(1e6), -1)
+	exp = "1000000"
+	if err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	_, err = canonicalBig(42, -1)
+	if err == nil {
+		t.Error("expected error here")
+	}
+}
+
+func TestSpelloutTyped(t *testing.T) {
+	lang := Language("sv")
+	defaultRules := RuleSet{
+		Name: "default",
+		Rules: []BaseRule{
+			NewStringRule(lang, "-x", "minus", " ", ">>"),
+			NewStringRule(lang, "x.x", "<<", " ", "komma", " ", ">>"),
+			NewIntRule(lang, 0, 10, "noll"),
+			NewIntRule(lang, 1, 10, "ett"),
+			NewIntRule(lang, 2, 10, "två"),
+			NewIntRule(lang, 3, 10, "tre"),
+			NewIntRule(lang, 4, 10, "fyra"),
+			NewIntRule(lang, 5, 10, "fem"),
+			NewIntRule(lang, 6, 10, "sex"),
+			NewIntRule(lang, 7, 10, "sju"),
+			NewIntRule(lang, 8, 10, "åtta"),
+			NewIntRule(lang, 9, 10, "nio"),
+			NewIntRule(lang, 10, 10, "tio"),
+			NewIntRule(lang, 100, 10, "<<", " ", "hundra", "[ ]", "[>>]"),
+			NewIntRule(lang, 1000, 10, "<<", " ", "tusen", "[ ]", "[>>]"),
+			NewIntRule(lang, 1000000, 10, "<<", " ", "miljoner", "[ ]", "[>>]"),
+		},
+	}
+	g, err := NewRuleSetGroup("SpelloutRules", lang, []RuleSet{defaultRules})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := NewRulePackage(lang, []RuleSetGroup{g}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var exp, res string
+
+	res, err = pkg.SpelloutInt64(-3, "SpelloutRules", "default", false)
+	exp = "minus tre"
+	if err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	res, err = pkg.SpelloutUint64(2000, "SpelloutRules", "default", false)
+	exp = "två tusen"
+	if err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	res, err = g.SpelloutFloat(1e6, -1, "default", false)
+	exp = "ett miljoner"
+	if err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	res, err = g.SpelloutFloat(math.Copysign(0, -1), -1, "default", false)
+	exp = "noll"
+	if err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	res, err = pkg.SpelloutFloat(0.3000000000000001, 1, "SpelloutRules", "default", false)
+	exp = "noll komma tre"
+	if err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	res, err = pkg.SpelloutBig(big.NewRat(5, 2), -1, "SpelloutRules", "default", false)
+	exp = "två komma fem"
+	if err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	res, err = g.SpelloutBig(big.NewInt(300), -1, "default", false)
+	exp = "tre hundra"
+	if err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+}
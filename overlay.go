@@ -0,0 +1,166 @@
+package rbnf
+
+import (
+	"fmt"
+	"sort"
+)
+
+// OverlayMode defines how an overlay rule set is applied to a rule package
+type OverlayMode string
+
+const (
+	// OverlayReplace adds the rule set, or replaces an existing rule set with the same name (default)
+	OverlayReplace OverlayMode = "replace"
+	// OverlayMerge replaces or inserts individual rules by base value in an existing rule set (or adds the rule set if it doesn't exist)
+	OverlayMerge OverlayMode = "merge"
+)
+
+// ParseOverlayMode returns the overlay mode for a string (replace or merge). The empty string is parsed as OverlayReplace.
+func ParseOverlayMode(s string) (OverlayMode, error) {
+	switch OverlayMode(s) {
+	case "", OverlayReplace:
+		return OverlayReplace, nil
+	case OverlayMerge:
+		return OverlayMerge, nil
+	default:
+		return "", fmt.Errorf("unknown overlay mode: %s", s)
+	}
+}
+
+// OverlayRuleSet is a rule set to be applied to the named rule set group of a package
+type OverlayRuleSet struct {
+	Group   string
+	Mode    OverlayMode
+	RuleSet RuleSet
+}
+
+// Overlay is a set of local changes to a rule package, used to patch CLDR rules without changing the CLDR files
+// (see RulePackage.ApplyOverlay). Rule references in the overlay may point to rule sets in the package.
+type Overlay struct {
+	// Language, if set, must match the language of the package the overlay is applied to
+	Language Language
+	RuleSets []OverlayRuleSet
+}
+
+// sameBase reports whether two rules have the same base value, including radix and decrement (1100/100 and 100> are not
+// the same as 1100 and 100)
+func sameBase(a, b BaseRule) bool {
+	if a.Base.IsInt() != b.Base.IsInt() {
+		return false
+	}
+	if a.Base.IsInt() {
+		return a.Base.Int == b.Base.Int && a.Base.Radix == b.Base.Radix && a.Base.Decrement == b.Base.Decrement
+	}
+	return a.Base.String == b.Base.String
+}
+
+// mergeRules replaces the rules with the same base values as the overlay rules, and inserts the others.
+// Integer rules are inserted in ascending order of base value, string rules (such as -x and x.x)
+// after the existing string rules.
+func mergeRules(rules []BaseRule, overlay []BaseRule) []BaseRule {
+	res := append([]BaseRule{}, rules...)
+	for _, o := range overlay {
+		replaced := false
+		for i, r := range res {
+			if sameBase(r, o) {
+				res[i] = o
+				replaced = true
+				break
+			}
+		}
+		if replaced {
+			continue
+		}
+		pos := len(res)
+		for i, r := range res {
+			if r.Base.IsInt() && (!o.Base.IsInt() || r.Base.Int > o.Base.Int) {
+				pos = i
+				break
+			}
+		}
+		res = append(res[:pos], append([]BaseRule{o}, res[pos:]...)...)
+	}
+	return res
+}
+
+// validateRuleOrder checks that integer rules are in ascending order of base value, as required by the rule matcher
+func validateRuleOrder(rs RuleSet) error {
+	ints := []int{}
+	for _, r := range rs.Rules {
+		if r.Base.IsInt() {
+			ints = append(ints, r.Base.Int)
+		}
+	}
+	if !sort.IntsAreSorted(ints) {
+		return fmt.Errorf("rules in rule set %s are not in ascending order", rs.Name)
+	}
+	return nil
+}
+
+// ApplyOverlay returns a copy of the package with the overlay applied. The package itself is not modified.
+// Overlay rule sets for groups not in the package create new groups. The resulting package is validated as
+// a whole: all rule references must resolve, and integer rules must be in ascending order.
+func (r *RulePackage) ApplyOverlay(overlay Overlay) (RulePackage, error) {
+	if overlay.Language != "" && overlay.Language != r.Language {
+		return RulePackage{}, fmt.Errorf("overlay language %s does not match package language %s", overlay.Language, r.Language)
+	}
+
+	res := *r
+	res.RuleSetGroups = nil
+	for _, g := range r.RuleSetGroups {
+		ruleSets := make(map[string]RuleSet)
+		for name, rs := range g.RuleSets {
+			ruleSets[name] = rs
+		}
+		g.RuleSets = ruleSets
+		res.RuleSetGroups = append(res.RuleSetGroups, g)
+	}
+
+	changed := make(map[string]bool)
+	for _, o := range overlay.RuleSets {
+		if o.Group == "" || o.RuleSet.Name == "" {
+			return RulePackage{}, fmt.Errorf("overlay rule set must have a group and a name: %s/%s", o.Group, o.RuleSet.Name)
+		}
+		gi := -1
+		for i, g := range res.RuleSetGroups {
+			if g.Name == o.Group {
+				gi = i
+			}
+		}
+		if gi < 0 {
+			res.RuleSetGroups = append(res.RuleSetGroups, RuleSetGroup{Name: o.Group, Language: r.Language, RuleSets: make(map[string]RuleSet)})
+			gi = len(res.RuleSetGroups) - 1
+		}
+		g := res.RuleSetGroups[gi]
+		existing, exists := g.RuleSets[o.RuleSet.Name]
+		switch o.Mode {
+		case "", OverlayReplace:
+			g.RuleSets[o.RuleSet.Name] = o.RuleSet
+		case OverlayMerge:
+			if !exists {
+				g.RuleSets[o.RuleSet.Name] = o.RuleSet
+				break
+			}
+			existing.Rules = mergeRules(existing.Rules, o.RuleSet.Rules)
+			g.RuleSets[o.RuleSet.Name] = existing
+		default:
+			return RulePackage{}, fmt.Errorf("unknown overlay mode for rule set %s: %s", o.RuleSet.Name, o.Mode)
+		}
+		changed[g.Name+"/"+o.RuleSet.Name] = true
+	}
+
+	for _, g := range res.RuleSetGroups {
+		if err := g.Validate(); err != nil {
+			return RulePackage{}, fmt.Errorf("invalid rule set group %s after overlay : %v", g.Name, err)
+		}
+		for name, rs := range g.RuleSets {
+			if !changed[g.Name+"/"+name] {
+				continue
+			}
+			if err := validateRuleOrder(rs); err != nil {
+				return RulePackage{}, fmt.Errorf("invalid rule set group %s after overlay : %v", g.Name, err)
+			}
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,128 @@
+package rbnf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyOverlay(t *testing.T) {
+	lang := Language("sv")
+	numbering := RuleSet{
+		Name: "spellout-numbering",
+		Rules: []BaseRule{
+			NewStringRule(lang, "-x", "minus ", ">>"),
+			NewIntRule(lang, 0, 10, "noll"),
+			NewIntRule(lang, 1, 10, "ett"),
+			NewIntRule(lang, 2, 10, "två"),
+			NewIntRule(lang, 10, 10, "tio"),
+		},
+	}
+	g, err := NewRuleSetGroup("SpelloutRules", lang, []RuleSet{numbering})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := NewRulePackage(lang, []RuleSetGroup{g}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	overlay := Overlay{
+		Language: lang,
+		RuleSets: []OverlayRuleSet{
+			{Group: "SpelloutRules", Mode: OverlayMerge, RuleSet: RuleSet{
+				Name: "spellout-numbering",
+				Rules: []BaseRule{
+					NewIntRule(lang, 1, 10, "en"),
+					NewIntRule(lang, 3, 10, "tre"),
+					NewStringRule(lang, "x.x", "<<", " komma ", ">>"),
+				},
+			}},
+			{Group: "SpelloutRules", RuleSet: RuleSet{
+				Name:  "spellout-house-number",
+				Rules: []BaseRule{NewIntRule(lang, 0, 10, "=%spellout-numbering=")},
+			}},
+			{Group: "OtherRules", RuleSet: RuleSet{
+				Name:  "other",
+				Rules: []BaseRule{NewIntRule(lang, 0, 10, "annat")},
+			}},
+		},
+	}
+	patched, err := pkg.ApplyOverlay(overlay)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		pkg     RulePackage
+		group   string
+		ruleSet string
+		input   string
+		exp     string
+	}{
+		{pkg, "SpelloutRules", "spellout-numbering", "1", "ett"},
+		{patched, "SpelloutRules", "spellout-numbering", "1", "en"},
+		{patched, "SpelloutRules", "spellout-numbering", "2", "två"},
+		{patched, "SpelloutRules", "spellout-numbering", "3", "tre"},
+		{patched, "SpelloutRules", "spellout-numbering", "-3", "minus tre"},
+		{patched, "SpelloutRules", "spellout-numbering", "1.2", "en komma två"},
+		{patched, "SpelloutRules", "spellout-house-number", "10", "tio"},
+		{patched, "OtherRules", "other", "1", "annat"},
+	}
+	for _, test := range tests {
+		res, err := test.pkg.Spellout(test.input, test.group, test.ruleSet, false)
+		if err != nil {
+			t.Error(err)
+		} else if res != test.exp {
+			t.Errorf(fs, test.exp, res)
+		}
+	}
+
+	// rule order after merge
+	var bases []string
+	for _, r := range patched.RuleSetGroups[0].RuleSets["spellout-numbering"].Rules {
+		bases = append(bases, r.Base.ToString())
+	}
+	if exp, res := 7, len(bases); exp != res {
+		t.Errorf(fs, exp, res)
+	} else if exp, res := "x.x", bases[1]; exp != res {
+		t.Errorf(fs, exp, res)
+	}
+
+	// the original package is unchanged
+	if _, ok := pkg.RuleSetGroups[0].RuleSets["spellout-house-number"]; ok {
+		t.Error("original package was modified")
+	}
+	if exp, res := 5, len(pkg.RuleSetGroups[0].RuleSets["spellout-numbering"].Rules); exp != res {
+		t.Errorf(fs, exp, res)
+	}
+
+	for _, o := range []Overlay{
+		{Language: "en"},
+		{RuleSets: []OverlayRuleSet{{Group: "SpelloutRules", RuleSet: RuleSet{Name: "x", Rules: []BaseRule{NewIntRule(lang, 0, 10, "=%no-such-rule-set=")}}}}},
+		{RuleSets: []OverlayRuleSet{{Group: "SpelloutRules", RuleSet: RuleSet{Name: "x", Rules: []BaseRule{NewIntRule(lang, 10, 10, "tio"), NewIntRule(lang, 1, 10, "ett")}}}}},
+		{RuleSets: []OverlayRuleSet{{Group: "SpelloutRules", Mode: "append", RuleSet: RuleSet{Name: "x"}}}},
+		{RuleSets: []OverlayRuleSet{{RuleSet: RuleSet{Name: "x"}}}},
+	} {
+		if _, err := pkg.ApplyOverlay(o); err == nil {
+			t.Errorf("expected error for overlay %#v", o)
+		}
+	}
+}
+
+func TestMergeRulesRadix(t *testing.T) {
+	lang := Language("sv")
+	decrement := NewIntRule(lang, 100, 10, "hundra-")
+	decrement.Base.Decrement = 1
+	rules := mergeRules(
+		[]BaseRule{NewIntRule(lang, 100, 10, "hundra"), NewIntRule(lang, 1100, 10, "elvahundra")},
+		[]BaseRule{NewIntRule(lang, 1100, 100, "elva-hundra"), decrement, NewIntRule(lang, 100, 10, "etthundra")},
+	)
+	var res []string
+	for _, r := range rules {
+		res = append(res, r.String())
+	}
+	exp := "100 (10) => 'etthundra' | 100> (10) => 'hundra-' | 1100 (10) => 'elvahundra' | 1100 (100) => 'elva-hundra'"
+	if got := strings.Join(res, " | "); got != exp {
+		t.Errorf(fs, exp, got)
+	}
+}
@@ -0,0 +1,243 @@
+package rbnf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RoundingMode is a rounding mode, as defined by ICU (https://unicode-org.github.io/icu/userguide/format_parse/numbers/rounding-modes)
+type RoundingMode int
+
+const (
+	// RoundHalfEven rounds towards the nearest neighbour, or towards the even neighbour if both are equally near (default)
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp rounds towards the nearest neighbour, or away from zero if both are equally near
+	RoundHalfUp
+	// RoundHalfDown rounds towards the nearest neighbour, or towards zero if both are equally near
+	RoundHalfDown
+	// RoundUp rounds away from zero
+	RoundUp
+	// RoundDown rounds towards zero (truncation)
+	RoundDown
+	// RoundCeiling rounds towards positive infinity
+	RoundCeiling
+	// RoundFloor rounds towards negative infinity
+	RoundFloor
+	// RoundUnnecessary fails if rounding is needed
+	RoundUnnecessary
+)
+
+var roundingModeNames = map[RoundingMode]string{
+	RoundHalfEven:    "halfEven",
+	RoundHalfUp:      "halfUp",
+	RoundHalfDown:    "halfDown",
+	RoundUp:          "up",
+	RoundDown:        "down",
+	RoundCeiling:     "ceiling",
+	RoundFloor:       "floor",
+	RoundUnnecessary: "unnecessary",
+}
+
+func (m RoundingMode) String() string {
+	if s, ok := roundingModeNames[m]; ok {
+		return s
+	}
+	return fmt.Sprintf("RoundingMode(%d)", int(m))
+}
+
+// ParseRoundingMode returns the rounding mode for an ICU rounding mode name (halfEven, halfUp, halfDown, up, down, ceiling, floor or unnecessary)
+func ParseRoundingMode(s string) (RoundingMode, error) {
+	for m, name := range roundingModeNames {
+		if name == s {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown rounding mode: %s", s)
+}
+
+type precision struct {
+	minFractionDigits    int
+	maxFractionDigits    int // negative: no limit
+	minSignificantDigits int
+	maxSignificantDigits int // zero: not used
+	rounding             RoundingMode
+}
+
+// PrecisionOption sets the precision used for decimal input, see ApplyPrecision
+type PrecisionOption func(*precision)
+
+// MinFractionDigits pads the fraction with zeros to at least n digits
+func MinFractionDigits(n int) PrecisionOption {
+	return func(p *precision) { p.minFractionDigits = n }
+}
+
+// MaxFractionDigits rounds the fraction to at most n digits
+func MaxFractionDigits(n int) PrecisionOption {
+	return func(p *precision) { p.maxFractionDigits = n }
+}
+
+// MinSignificantDigits pads the number with fraction zeros to at least n significant digits
+func MinSignificantDigits(n int) PrecisionOption {
+	return func(p *precision) { p.minSignificantDigits = n }
+}
+
+// MaxSignificantDigits rounds the number to at most n significant digits
+func MaxSignificantDigits(n int) PrecisionOption {
+	return func(p *precision) { p.maxSignificantDigits = n }
+}
+
+// Rounding sets the rounding mode (default RoundHalfEven)
+func Rounding(mode RoundingMode) PrecisionOption {
+	return func(p *precision) { p.rounding = mode }
+}
+
+// roundDigits rounds a digit string to its first n digits using the rounding mode, filling the
+// remaining positions with zeros. The result may be one digit longer than the input (999 => 1000).
+func roundDigits(digits string, n int, neg bool, mode RoundingMode) (string, error) {
+	if n >= len(digits) {
+		return digits, nil
+	}
+	kept, rest := digits[:n], digits[n:]
+	restZero := strings.Trim(rest, "0") == ""
+	if restZero {
+		return digits, nil
+	}
+	tailZero := strings.Trim(rest[1:], "0") == ""
+	var increment bool
+	switch mode {
+	case RoundUnnecessary:
+		return "", fmt.Errorf("rounding necessary for %s", digits)
+	case RoundDown:
+		increment = false
+	case RoundUp:
+		increment = true
+	case RoundCeiling:
+		increment = !neg
+	case RoundFloor:
+		increment = neg
+	case RoundHalfUp:
+		increment = rest[0] >= '5'
+	case RoundHalfDown:
+		increment = rest[0] > '5' || (rest[0] == '5' && !tailZero)
+	case RoundHalfEven:
+		lastOdd := n > 0 && (kept[n-1]-'0')%2 == 1
+		increment = rest[0] > '5' || (rest[0] == '5' && (!tailZero || lastOdd))
+	default:
+		return "", fmt.Errorf("unknown rounding mode: %v", mode)
+	}
+	res := []byte(kept)
+	if increment {
+		i := len(res) - 1
+		for ; i >= 0; i-- {
+			if res[i] == '9' {
+				res[i] = '0'
+			} else {
+				res[i]++
+				break
+			}
+		}
+		if i < 0 {
+			res = append([]byte{'1'}, res...)
+		}
+	}
+	return string(res) + strings.Repeat("0", len(rest)), nil
+}
+
+// ApplyPrecision rounds and pads decimal input in the canonical input format (ASCII digits, optional
+// leading '-' and optional '.' for decimals), using exact decimal arithmetic. If significant digits are
+// set, fraction digit settings are ignored (as in ICU). Trailing fraction zeros are removed down to the
+// minimum number of digits: with default options, "2.50" is returned as "2.5", and with
+// MinFractionDigits(2), as "2.50".
+func ApplyPrecision(input string, opts ...PrecisionOption) (string, error) {
+	p := precision{maxFractionDigits: -1}
+	for _, opt := range opts {
+		opt(&p)
+	}
+
+	neg := strings.HasPrefix(input, "-")
+	s := strings.TrimPrefix(input, "-")
+	intPart, fracPart := s, ""
+	if pts := strings.SplitN(s, ".", 2); len(pts) == 2 {
+		intPart, fracPart = pts[0], pts[1]
+	}
+	if intPart == "" || strings.Trim(intPart+fracPart, "0123456789") != "" {
+		return "", fmt.Errorf("invalid decimal input: %s", input)
+	}
+	intPart = strings.TrimLeft(intPart, "0")
+
+	// number of digits to keep, counted from the first digit of intPart+fracPart
+	digits := intPart + fracPart
+	point := len(intPart)
+	useSignificant := p.minSignificantDigits > 0 || p.maxSignificantDigits > 0
+	keep := len(digits)
+	if useSignificant && p.maxSignificantDigits > 0 {
+		first := len(digits) - len(strings.TrimLeft(digits, "0"))
+		keep = first + p.maxSignificantDigits
+	} else if !useSignificant && p.maxFractionDigits >= 0 {
+		keep = point + p.maxFractionDigits
+	}
+	rounded, err := roundDigits(digits, keep, neg, p.rounding)
+	if err != nil {
+		return "", fmt.Errorf("couldn't apply precision to %s : %v", input, err)
+	}
+	if len(rounded) > len(digits) {
+		point++
+	}
+	// positions after the rounding position are zeros, removed below
+	intPart, fracPart = rounded[:point], rounded[point:]
+
+	// minimum number of fraction digits
+	minFrac := p.minFractionDigits
+	if useSignificant {
+		minFrac = 0
+		if p.minSignificantDigits > 0 {
+			var sigFrac int
+			if strings.Trim(intPart, "0") == "" {
+				// number < 1: leading fraction zeros are not significant
+				sigFrac = len(fracPart) - len(strings.TrimLeft(fracPart, "0")) + p.minSignificantDigits
+			} else {
+				sigFrac = p.minSignificantDigits - len(strings.TrimLeft(intPart, "0"))
+			}
+			if sigFrac > minFrac {
+				minFrac = sigFrac
+			}
+		}
+	}
+	for len(fracPart) > minFrac && strings.HasSuffix(fracPart, "0") {
+		fracPart = fracPart[:len(fracPart)-1]
+	}
+	for len(fracPart) < minFrac {
+		fracPart += "0"
+	}
+
+	intPart = strings.TrimLeft(intPart, "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+	res := intPart
+	if fracPart != "" {
+		res = res + "." + fracPart
+	}
+	if neg && strings.Trim(res, "0.") != "" {
+		res = "-" + res
+	}
+	return res, nil
+}
+
+// SpelloutWithPrecision rounds and pads the decimal input (see ApplyPrecision), and spells it out using the named rule set group and rule set
+func (r *RulePackage) SpelloutWithPrecision(input string, groupName string, ruleSetName string, debug bool, opts ...PrecisionOption) (string, error) {
+	rounded, err := ApplyPrecision(input, opts...)
+	if err != nil {
+		return "", err
+	}
+	return r.Spellout(rounded, groupName, ruleSetName, debug)
+}
+
+// SpelloutWithPrecision rounds and pads the decimal input (see ApplyPrecision), and spells it out using the named rule set
+func (g *RuleSetGroup) SpelloutWithPrecision(input string, ruleSetName string, debug bool, opts ...PrecisionOption) (string, error) {
+	rounded, err := ApplyPrecision(input, opts...)
+	if err != nil {
+		return "", err
+	}
+	return g.Spellout(rounded, ruleSetName, debug)
+}
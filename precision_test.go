@@ -0,0 +1,120 @@
+package rbnf
+
+import (
+	"testing"
+)
+
+func TestApplyPrecision(t *testing.T) {
+	tests := []struct {
+		input string
+		opts  []PrecisionOption
+		exp   string
+	}{
+		{"2.50", nil, "2.5"},
+		{"2.50", []PrecisionOption{MinFractionDigits(2)}, "2.50"},
+		{"2.5", []PrecisionOption{MinFractionDigits(2)}, "2.50"},
+		{"3.14159265", []PrecisionOption{MaxFractionDigits(2)}, "3.14"},
+		{"3.14159265", []PrecisionOption{MaxFractionDigits(4)}, "3.1416"},
+		{"2.5", []PrecisionOption{MaxFractionDigits(0)}, "2"},
+		{"3.5", []PrecisionOption{MaxFractionDigits(0)}, "4"},
+		{"0.5", []PrecisionOption{MaxFractionDigits(0)}, "0"},
+		{"2.5", []PrecisionOption{MaxFractionDigits(0), Rounding(RoundHalfUp)}, "3"},
+		{"2.51", []PrecisionOption{MaxFractionDigits(0), Rounding(RoundHalfDown)}, "3"},
+		{"2.5", []PrecisionOption{MaxFractionDigits(0), Rounding(RoundHalfDown)}, "2"},
+		{"-2.1", []PrecisionOption{MaxFractionDigits(0), Rounding(RoundCeiling)}, "-2"},
+		{"-2.1", []PrecisionOption{MaxFractionDigits(0), Rounding(RoundFloor)}, "-3"},
+		{"2.1", []PrecisionOption{MaxFractionDigits(0), Rounding(RoundUp)}, "3"},
+		{"2.9", []PrecisionOption{MaxFractionDigits(0), Rounding(RoundDown)}, "2"},
+		{"0.3", []PrecisionOption{MaxFractionDigits(0), Rounding(RoundUp)}, "1"},
+		{"9.999", []PrecisionOption{MaxFractionDigits(2)}, "10"},
+		{"9.999", []PrecisionOption{MaxFractionDigits(2), MinFractionDigits(2)}, "10.00"},
+		{"-0.001", []PrecisionOption{MaxFractionDigits(2)}, "0"},
+		{"2.00", []PrecisionOption{Rounding(RoundUnnecessary), MaxFractionDigits(0)}, "2"},
+		{"12345", []PrecisionOption{MaxSignificantDigits(2)}, "12000"},
+		{"12345", []PrecisionOption{MaxSignificantDigits(2), MaxFractionDigits(3)}, "12000"},
+		{"0.0012345", []PrecisionOption{MaxSignificantDigits(3)}, "0.00123"},
+		{"1.5", []PrecisionOption{MinSignificantDigits(3)}, "1.50"},
+		{"0.05", []PrecisionOption{MinSignificantDigits(2)}, "0.050"},
+		{"120", []PrecisionOption{MinSignificantDigits(2)}, "120"},
+		{"99.96", []PrecisionOption{MaxSignificantDigits(3)}, "100"},
+	}
+	for _, test := range tests {
+		res, err := ApplyPrecision(test.input, test.opts...)
+		if err != nil {
+			t.Errorf("%s: %v", test.input, err)
+		} else if res != test.exp {
+			t.Errorf("%s: "+fs, test.input, test.exp, res)
+		}
+	}
+
+	if _, err := ApplyPrecision("2.5", MaxFractionDigits(0), Rounding(RoundUnnecessary)); err == nil {
+		t.Error("expected error here")
+	}
+	if _, err := ApplyPrecision("2,5"); err == nil {
+		t.Error("expected error here")
+	}
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	for m := RoundHalfEven; m <= RoundUnnecessary; m++ {
+		res, err := ParseRoundingMode(m.String())
+		if err != nil {
+			t.Error(err)
+		} else if res != m {
+			t.Errorf(fs, m, res)
+		}
+	}
+	if _, err := ParseRoundingMode("halfOdd"); err == nil {
+		t.Error("expected error here")
+	}
+}
+
+func TestSpelloutWithPrecision(t *testing.T) {
+	lang := Language("en")
+	numbering := RuleSet{
+		Name: "spellout-numbering",
+		Rules: []BaseRule{
+			NewStringRule(lang, "x.x", "<<", " ", "point", " ", ">%digits>"),
+			NewIntRule(lang, 0, 10, "zero"),
+			NewIntRule(lang, 1, 10, "one"),
+			NewIntRule(lang, 2, 10, "two"),
+			NewIntRule(lang, 3, 10, "three"),
+			NewIntRule(lang, 4, 10, "four"),
+			NewIntRule(lang, 5, 10, "five"),
+		},
+	}
+	// spells out the fraction digit by digit
+	digits := RuleSet{
+		Name:    "digits",
+		Private: true,
+		Rules: []BaseRule{
+			NewIntRule(lang, 0, 10, "=%spellout-numbering="),
+			NewIntRule(lang, 10, 10, "<<", " ", ">>"),
+		},
+	}
+	g, err := NewRuleSetGroup("SpelloutRules", lang, []RuleSet{numbering, digits})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := g.SpelloutWithPrecision("2.50", "spellout-numbering", false)
+	if exp := "two point five"; err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	res, err = g.SpelloutWithPrecision("2.50", "spellout-numbering", false, MinFractionDigits(2))
+	if exp := "two point five zero"; err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	res, err = g.SpelloutWithPrecision("3.14159265", "spellout-numbering", false, MaxFractionDigits(2))
+	if exp := "three point one four"; err != nil {
+		t.Error(err)
+	} else if res != exp {
+		t.Errorf(fs, exp, res)
+	}
+}
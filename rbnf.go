@@ -8,8 +8,6 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/google/uuid"
-
 	"golang.org/x/text/feature/plural"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
@@ -26,6 +24,8 @@ type Base struct {
 	// Int base
 	Int   int
 	Radix int // only used for Int base
+	// Decrement is the number of > modifiers of the base value (100>), each decreasing the exponent of the divisor by one (only used for Int base)
+	Decrement int
 
 	// String base
 	String string
@@ -48,9 +48,14 @@ func NewBaseInt(n int, radix int) Base {
 
 type Language string
 
+// Tag returns the BCP 47 language tag for the language (de_CH => de-CH)
+func (l Language) Tag() language.Tag {
+	return language.Make(strings.Replace(string(l), "_", "-", -1))
+}
+
 func (b Base) ToString() string {
 	if b.IsInt() {
-		return fmt.Sprintf("%d (%d)", b.Int, b.Radix)
+		return fmt.Sprintf("%d%s (%d)", b.Int, strings.Repeat(">", b.Decrement), b.Radix)
 	}
 	return b.String
 }
@@ -62,18 +67,35 @@ func (b Base) Value() string {
 	return b.String
 }
 
+// PluralFormatter selects an inflection suffix for a number, using the CLDR plural
+// rules of the formatter's language (rules formulated as $(ordinal,one{:a}other{:e})$).
+// All state needed for formatting is kept in the formatter itself, so nothing is
+// registered in any global message catalog.
 type PluralFormatter struct {
-	printer     *message.Printer
-	title       string
+	lang        Language
+	tag         language.Tag
+	rules       *plural.Rules
+	cases       []pluralCase
 	format      string
 	initialized bool
 }
 
+type pluralCase struct {
+	selector string
+	text     string
+}
+
 func (f PluralFormatter) String() string {
 	return f.format
 }
 
+// Language returns the language of the formatter's plural rules
+func (f PluralFormatter) Language() Language {
+	return f.lang
+}
+
 type NumericFormatter struct {
+	lang        Language
 	printer     *message.Printer
 	format      string
 	initialized bool
@@ -83,6 +105,17 @@ func (f NumericFormatter) String() string {
 	return f.format
 }
 
+// Language returns the language used for formatting numbers
+func (f NumericFormatter) Language() Language {
+	return f.lang
+}
+
+// NewNumericFormatter creates a formatter for a number pattern (such as #,##0), formatting numbers for the language
+func NewNumericFormatter(lang Language, format string) NumericFormatter {
+	p := message.NewPrinter(language.Make(string(lang)))
+	return NumericFormatter{lang: lang, printer: p, format: format, initialized: true}
+}
+
 type Sub struct {
 	Optional         bool
 	Orth             string
@@ -92,17 +125,34 @@ type Sub struct {
 	Operation        string
 }
 
+var pluralForms = map[string]plural.Form{
+	"other": plural.Other,
+	"zero":  plural.Zero,
+	"one":   plural.One,
+	"two":   plural.Two,
+	"few":   plural.Few,
+	"many":  plural.Many,
+}
+
 func NewPluralFormatter(lang Language, fmtString string) (PluralFormatter, error) {
 	//$(ordinal,one{:a}other{:e})$
 	f := fmtString
 	f = strings.TrimPrefix(f, "$(")
 	f = strings.TrimSuffix(f, ")$")
-	fs := strings.Split(f, ",")
-	title := uuid.New().String() // fs[0]
+	fs := strings.SplitN(f, ",", 2)
 	if len(fs) != 2 {
 		return PluralFormatter{}, fmt.Errorf("invalid plural formatter string '%s' (got %d item(s): %#v, expected 2)", f, len(fs), fs)
 	}
-	cases := []interface{}{}
+	var rules *plural.Rules
+	switch fs[0] {
+	case "cardinal":
+		rules = plural.Cardinal
+	case "ordinal":
+		rules = plural.Ordinal
+	default:
+		return PluralFormatter{}, fmt.Errorf("invalid plural formatter string '%s' (unknown plural type '%s')", f, fs[0])
+	}
+	cases := []pluralCase{}
 	for _, sub := range strings.Split(fs[1], "}") {
 		if sub == "" {
 			continue
@@ -111,14 +161,96 @@ func NewPluralFormatter(lang Language, fmtString string) (PluralFormatter, error
 		if len(fs) != 2 {
 			return PluralFormatter{}, fmt.Errorf("invalid plural formatter string '%s' (got %d item(s): %#v, expected 2)", sub, len(fs), fs)
 		}
-		cases = append(cases, fs[0])
-		cases = append(cases, fs[1])
+		if err := validatePluralSelector(fs[0]); err != nil {
+			return PluralFormatter{}, fmt.Errorf("invalid plural formatter string '%s' : %v", f, err)
+		}
+		cases = append(cases, pluralCase{selector: fs[0], text: fs[1]})
+	}
+	return PluralFormatter{lang: lang, tag: language.Make(string(lang)), rules: rules, cases: cases, format: fmtString, initialized: true}, nil
+}
+
+func validatePluralSelector(selector string) error {
+	if strings.HasPrefix(selector, "=") || strings.HasPrefix(selector, "<") {
+		if _, err := strconv.Atoi(selector[1:]); err != nil {
+			return fmt.Errorf("invalid number in selector '%s'", selector)
+		}
+		return nil
+	}
+	if _, ok := pluralForms[selector]; !ok {
+		return fmt.Errorf("invalid plural form '%s'", selector)
 	}
-	//fmt.Printf("NewPluralFormatter cases: %#v\n", cases)
-	l := language.Make(string(lang))
-	message.Set(l, title, plural.Selectf(1, "%d", cases...))
-	p := message.NewPrinter(l)
-	return PluralFormatter{printer: p, format: fmtString, title: title, initialized: true}, nil
+	return nil
+}
+
+// pluralOperandMod is the modulus for plural operands too large for an int (see plural.Rules.MatchPlural)
+const pluralOperandMod = 10000000
+
+// pluralOperand converts a string of digits to a plural operand. Values too large for an int are reduced
+// modulo 10,000,000, as allowed by plural.Rules.MatchPlural, keeping them above any value that fits in
+// seven digits. The second return value is false if the value was reduced.
+func pluralOperand(digits string) (int, bool) {
+	if n, err := strconv.Atoi(digits); err == nil {
+		return n, true
+	}
+	n, _ := strconv.Atoi(digits[len(digits)-7:])
+	return n + pluralOperandMod, false
+}
+
+// pluralOperands computes the CLDR plural operands (see https://unicode.org/reports/tr35/tr35-numbers.html#Operands)
+// for a decimal input string. The integer part is kept in full; exact is false if it was too large for an int,
+// and reduced (see pluralOperand).
+func pluralOperands(input string) (i, v, w, f, t int, exact bool, err error) {
+	s := strings.TrimPrefix(input, "-")
+	intPart, fracPart := s, ""
+	if pts := strings.Split(s, "."); len(pts) == 2 {
+		intPart, fracPart = pts[0], pts[1]
+	}
+	if intPart == "" || strings.Trim(intPart+fracPart, "0123456789") != "" {
+		return 0, 0, 0, 0, 0, false, fmt.Errorf("invalid numeric input: %s", input)
+	}
+	i, exact = pluralOperand(intPart)
+	trimmed := strings.TrimRight(fracPart, "0")
+	v, w = len(fracPart), len(trimmed)
+	if v > 0 {
+		f, _ = pluralOperand(fracPart)
+	}
+	if w > 0 {
+		t, _ = pluralOperand(trimmed)
+	}
+	return i, v, w, f, t, exact, nil
+}
+
+// Select returns the text of the first case matching the input number. Exact (=N) and less than (<N)
+// selectors are compared with the whole input number. The other case is used only if no other case matches.
+func (f PluralFormatter) Select(input string) (string, error) {
+	i, v, w, fr, t, exact, err := pluralOperands(input)
+	if err != nil {
+		return "", err
+	}
+	form := f.rules.MatchPlural(f.tag, i, v, w, fr, t)
+	other, hasOther := "", false
+	for _, c := range f.cases {
+		switch {
+		case strings.HasPrefix(c.selector, "="):
+			if n, _ := strconv.Atoi(c.selector[1:]); exact && v == 0 && i == n {
+				return c.text, nil
+			}
+		case strings.HasPrefix(c.selector, "<"):
+			if n, _ := strconv.Atoi(c.selector[1:]); exact && i < n {
+				return c.text, nil
+			}
+		case c.selector == "other":
+			if !hasOther {
+				other, hasOther = c.text, true
+			}
+		case pluralForms[c.selector] == form:
+			return c.text, nil
+		}
+	}
+	if hasOther {
+		return other, nil
+	}
+	return "", fmt.Errorf("no plural case for input %s in %s", input, f.format)
 }
 
 func ParseSub(sub string, lang Language) (Sub, error) {
@@ -145,8 +277,7 @@ func ParseSub(sub string, lang Language) (Sub, error) {
 		res.Operation = firstChar + firstChar
 		ref := strings.TrimPrefix(strings.TrimSuffix(sub, firstChar), firstChar)
 		if strings.HasPrefix(ref, "#") || (res.Operation != "" && strings.Contains(ref, "0")) {
-			p := message.NewPrinter(language.Make(string(lang)))
-			res.NumericFormatter = NumericFormatter{printer: p, format: ref, initialized: true}
+			res.NumericFormatter = NewNumericFormatter(lang, ref)
 		} else {
 			res.RuleRef = ref
 		}
@@ -291,6 +422,7 @@ func (b Base) Divisor() int {
 	for i := 1; exp(b.Radix, i) <= b.Int; i++ {
 		exponent = i
 	}
+	exponent -= b.Decrement
 	if exponent >= 0 {
 		divisor = exp(b.Radix, exponent)
 	} else {
@@ -362,13 +494,33 @@ type MatchResult struct {
 	ForwardRight string
 }
 
+// RulePackage holds the rule set groups of one language. A loaded RulePackage is
+// read-only during spellout, and is safe for concurrent use by multiple goroutines
+// as long as it is not modified.
 type RulePackage struct {
 	Language      Language
 	RuleSetGroups []RuleSetGroup
 	Debug         bool
+
+	// RuleSetNames overrides the built-in rule set names used by SpelloutCardinal, SpelloutOrdinal, etc
+	RuleSetNames map[Kind]string
+
+	// Fallback is the policy used when no rule matches the input. RuleSetFallbacks overrides it for named rule sets.
+	Fallback         FallbackPolicy
+	RuleSetFallbacks map[string]FallbackPolicy
 }
 
+// Spellout spells out the input string using the named rule set group and rule set. If no rule matches
+// the input, the package's fallback policy is applied (see SpelloutWithResult).
 func (r *RulePackage) Spellout(input string, groupName string, ruleSetName string, debug bool) (string, error) {
+	res, err := r.SpelloutWithResult(input, groupName, ruleSetName, debug)
+	if err != nil {
+		return "", err
+	}
+	return res.Output, nil
+}
+
+func (r *RulePackage) spellout(input string, groupName string, ruleSetName string, debug bool) (string, error) {
 	for _, g := range r.RuleSetGroups {
 		if g.Name == groupName {
 			res, err := g.Spellout(input, ruleSetName, debug)
@@ -385,6 +537,9 @@ type RuleSetGroup struct {
 	Name     string
 	Language Language
 	RuleSets map[string]RuleSet
+
+	// Coverage, if set, records the rule sets, rules and subs used during spellout (see Coverage)
+	Coverage *Coverage
 }
 
 func (g RuleSetGroup) FindRuleSet(ruleRef string) (RuleSet, bool) {
@@ -451,35 +606,33 @@ func NewRuleSetGroup(name string, lang Language, ruleSets []RuleSet) (RuleSetGro
 	return res, err
 }
 
-func (g *RuleSetGroup) findMatchingRule(input string, ruleSet RuleSet) (BaseRule, bool) {
+// findMatchingRule returns the matching rule, and its index in the rule set
+func (g *RuleSetGroup) findMatchingRule(input string, ruleSet RuleSet) (BaseRule, int, bool) {
 	var res BaseRule
+	var index = -1
 	var found = false
 
 	n, err := strconv.Atoi(input)
 
-	for _, r := range ruleSet.Rules {
+	for i, r := range ruleSet.Rules {
 		if r.Base.IsInt() {
 			if err != nil {
 				continue
 			}
 			if r.Base.Int <= n {
 				res = r
+				index = i
 				found = true
 			} else {
 				break
 			}
 		} else {
 			if _, matches := r.Match(input); matches {
-				return r, true
+				return r, i, true
 			}
 		}
 	}
-	// if !found { // fallback rule?
-	// 	if rs, ok := g.FindRuleSet("spellout-cardinal"); ok {
-	// 		return g.findMatchingRule(input, rs)
-	// 	}
-	// }
-	return res, found
+	return res, index, found
 }
 
 // details here: http://www.icu-project.org/applets/icu4j/4.1/docs-4_1_1/com/ibm/icu/text/DecimalFormat.html
@@ -508,22 +661,12 @@ func formatPlural(input string, formatter PluralFormatter, debug bool) (string,
 	if debug {
 		fmt.Fprintf(os.Stderr, "[rbnf.formatPlural] Input:%s Fmt:%s\n", input, formatter.format)
 	}
-	var numeric, inflectNumeric interface{}
-	var err error
-	i, err := strconv.ParseInt(input, 10, 64)
-	if err == nil {
-		numeric = i
-		inflectNumeric = i % 10
-	} else {
-		numeric, err = strconv.ParseFloat(input, 64)
-		inflectNumeric = numeric
-		if err != nil {
-			return input, err
-		}
+	res, err := formatter.Select(input)
+	if err != nil {
+		return input, err
 	}
-	res := formatter.printer.Sprintf(formatter.title, inflectNumeric)
 	if debug {
-		fmt.Fprintf(os.Stderr, "[rbnf.formatPlural] Parsed numeric: %v (%v) Res: %s\n", numeric, inflectNumeric, res)
+		fmt.Fprintf(os.Stderr, "[rbnf.formatPlural] Res: %s\n", res)
 	}
 	return res, nil
 }
@@ -538,9 +681,12 @@ func (g *RuleSetGroup) Spellout(input string, ruleSetName string, debug bool) (s
 }
 
 func (g *RuleSetGroup) spellout(input string, ruleSet RuleSet, debug bool) (string, error) {
-	matchedRule, ok := g.findMatchingRule(input, ruleSet)
+	if g.Coverage != nil {
+		g.Coverage.hitRuleSet(g.Name, ruleSet.Name)
+	}
+	matchedRule, ruleIndex, ok := g.findMatchingRule(input, ruleSet)
 	if !ok {
-		err := fmt.Errorf("no matching base rule for %s in rule set %s", input, ruleSet.Name)
+		err := &NoMatchError{Input: input, RuleSet: ruleSet.Name}
 		if debug {
 			fmt.Fprintf(os.Stderr, "[rbnf] %v : rule set: %#v\n", err, ruleSet)
 		}
@@ -559,8 +705,12 @@ func (g *RuleSetGroup) spellout(input string, ruleSet RuleSet, debug bool) (stri
 		fmt.Fprintf(os.Stderr, "[rbnf] Match result: %#v\n", match)
 	}
 
+	if g.Coverage != nil {
+		g.Coverage.hitRule(g.Name, ruleSet.Name, ruleIndex)
+	}
+
 	var subs = []string{}
-	for _, sub := range matchedRule.Subs {
+	for subIndex, sub := range matchedRule.Subs {
 		if debug {
 			fmt.Fprintf(os.Stderr, "[rbnf] Current sub: %#v\n", sub)
 		}
@@ -581,6 +731,9 @@ func (g *RuleSetGroup) spellout(input string, ruleSet RuleSet, debug bool) (stri
 			}
 		}
 
+		if g.Coverage != nil {
+			g.Coverage.hitSub(g.Name, ruleSet.Name, ruleIndex, subIndex)
+		}
 		if debug {
 			fmt.Fprintf(os.Stderr, "[rbnf] Accumulated subs: %#v\n", subs)
 		}
@@ -2,6 +2,7 @@ package rbnf
 
 import (
 	"strings"
+	"sync"
 	"testing"
 
 	"golang.org/x/text/language"
@@ -10,6 +11,55 @@ import (
 
 var fs = "Expected '%v', got '%v'"
 
+// testGroup is a minimal Swedish rule set group, shared by the tests of rule set graphs, vocabularies, FSTs, training
+// data and JSON. The CLDR files are tested in the xmlreader package.
+func testGroup(t *testing.T) RuleSetGroup {
+	lang := Language("sv")
+	ruleSets := []RuleSet{
+		{
+			Name: "spellout-numbering",
+			Rules: []BaseRule{
+				NewStringRule(lang, "-x", "minus ", ">>"),
+				NewIntRule(lang, 0, 10, "noll"),
+				NewIntRule(lang, 1, 10, "ett"),
+				NewIntRule(lang, 2, 10, "två"),
+				NewIntRule(lang, 3, 10, "tre"),
+				NewIntRule(lang, 4, 10, "=#,##0="),
+				NewIntRule(lang, 20, 10, "tjugo", "[>>]"),
+				NewIntRule(lang, 30, 10, "=#,##0="),
+				NewIntRule(lang, 100, 10, "<%%hundreds<", "[ ]", "[>>]"),
+				NewIntRule(lang, 400, 10, "=#,##0="),
+			},
+		},
+		{
+			Name:    "hundreds",
+			Private: true,
+			Rules: []BaseRule{
+				NewIntRule(lang, 1, 10, "ett", "hundra"),
+				NewIntRule(lang, 2, 10, "=%spellout-numbering=", "hundra"),
+			},
+		},
+		{
+			Name: "digits-ordinal",
+			Rules: []BaseRule{
+				NewIntRule(lang, 0, 10, "=#,##0=", ":e"),
+			},
+		},
+		{
+			Name:    "unused",
+			Private: true,
+			Rules: []BaseRule{
+				NewIntRule(lang, 0, 10, "oanvänd"),
+			},
+		},
+	}
+	g, err := NewRuleSetGroup("SpelloutRules", lang, ruleSets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
 func Test_exp(t *testing.T) {
 	if w, g := 10, exp(10, 1); w != g {
 		t.Errorf(fs, w, g)
@@ -779,3 +829,164 @@ func TestHashFormat(t *testing.T) {
 		t.Errorf(fs, exp, res)
 	}
 }
+
+func TestPluralFormatter(t *testing.T) {
+	var fmter PluralFormatter
+	var err error
+
+	fmter, err = NewPluralFormatter("en", "$(ordinal,one{st}two{nd}few{rd}other{th})$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for input, exp := range map[string]string{
+		"1":   "st",
+		"2":   "nd",
+		"3":   "rd",
+		"4":   "th",
+		"11":  "th",
+		"12":  "th",
+		"21":  "st",
+		"102": "nd",
+		"113": "th",
+	} {
+		res, err := fmter.Select(input)
+		if err != nil {
+			t.Error(err)
+		} else if res != exp {
+			t.Errorf(fs, exp, res)
+		}
+	}
+
+	fmter, err = NewPluralFormatter("ru", "$(cardinal,one{тысяча}few{тысячи}other{тысяч})$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for input, exp := range map[string]string{
+		"1":  "тысяча",
+		"3":  "тысячи",
+		"12": "тысяч",
+		"22": "тысячи",
+		"25": "тысяч",
+	} {
+		res, err := fmter.Select(input)
+		if err != nil {
+			t.Error(err)
+		} else if res != exp {
+			t.Errorf(fs, exp, res)
+		}
+	}
+
+	// integers were previously reduced to i%10 before plural selection, giving 11:a, 12:a and 111:a
+	fmter, err = NewPluralFormatter("sv", "$(ordinal,one{:a}other{:e})$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for input, exp := range map[string]string{
+		"1":   ":a",
+		"2":   ":a",
+		"3":   ":e",
+		"11":  ":e",
+		"12":  ":e",
+		"21":  ":a",
+		"111": ":e",
+		"122": ":a",
+	} {
+		res, err := fmter.Select(input)
+		if err != nil {
+			t.Error(err)
+		} else if res != exp {
+			t.Errorf("%s: "+fs, input, exp, res)
+		}
+	}
+
+	// exact matches and magnitudes use the whole number; other is only used if no other case matches
+	fmter, err = NewPluralFormatter("en", "$(cardinal,other{many}=10000001{exact}one{one})$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for input, exp := range map[string]string{
+		"1":                         "one",
+		"2":                         "many",
+		"10000001":                  "exact",
+		"20000001":                  "many",
+		"1000000000000000000000001": "many",
+		"1.0":                       "many",
+	} {
+		res, err := fmter.Select(input)
+		if err != nil {
+			t.Error(err)
+		} else if res != exp {
+			t.Errorf("%s: "+fs, input, exp, res)
+		}
+	}
+	fmter, err = NewPluralFormatter("en", "$(cardinal,<10000000{less}other{more})$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for input, exp := range map[string]string{
+		"9999999":                   "less",
+		"10000001":                  "more",
+		"1000000000000000000000001": "more",
+	} {
+		res, err := fmter.Select(input)
+		if err != nil {
+			t.Error(err)
+		} else if res != exp {
+			t.Errorf("%s: "+fs, input, exp, res)
+		}
+	}
+
+	_, err = NewPluralFormatter("en", "$(ordinal,single{st}other{th})$")
+	if err == nil {
+		t.Error("expected error here")
+	}
+	_, err = NewPluralFormatter("en", "$(dual,one{st}other{th})$")
+	if err == nil {
+		t.Error("expected error here")
+	}
+}
+
+// Run with go test -race to check that spellout does not touch shared state
+func TestConcurrentSpellout(t *testing.T) {
+	lang := Language("en")
+	ordinal := RuleSet{
+		Name: "digits-ordinal",
+		Rules: []BaseRule{
+			NewIntRule(lang, 0, 10, "=#,##0=", "$(ordinal,one{st}two{nd}few{rd}other{th})$"),
+		},
+	}
+	g, err := NewRuleSetGroup("OrdinalRules", lang, []RuleSet{ordinal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := NewRulePackage(lang, []RuleSetGroup{g}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"1":    "1st",
+		"2":    "2nd",
+		"3":    "3rd",
+		"11":   "11th",
+		"1022": "1,022nd",
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				for input, exp := range tests {
+					res, err := pkg.Spellout(input, "OrdinalRules", "digits-ordinal", false)
+					if err != nil {
+						t.Error(err)
+					} else if res != exp {
+						t.Errorf(fs, exp, res)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,145 @@
+package rbnf
+
+import (
+	"fmt"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+// Loader loads a rule package, see Registry.Register
+type Loader func() (RulePackage, error)
+
+type registryEntry struct {
+	tag  language.Tag
+	load Loader
+	mu   sync.Mutex
+	pkg  *RulePackage
+}
+
+// get returns the package, loading it if needed. Only successful loads are kept: after a failure,
+// the next request calls the loader again.
+func (e *registryEntry) get() (*RulePackage, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.pkg != nil {
+		return e.pkg, nil
+	}
+	pkg, err := e.load()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't load rule package for %s : %v", e.tag, err)
+	}
+	e.pkg = &pkg
+	return e.pkg, nil
+}
+
+// Registry holds rule packages for many languages, keyed by BCP 47 language tag. Packages are loaded
+// lazily, the first time they are requested; after a failed load, the loader is called again on the next
+// request. Requested tags are resolved to the best registered package by following the CLDR parent
+// locales (de-AT => de, zh-Hant-HK => zh-Hant, en-IN => en-001 => en), and then using a language.Matcher
+// (sr-Cyrl-RS => sr, nn => nb). A Registry is safe for concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	tags     []language.Tag
+	entries  map[language.Tag]*registryEntry
+	matcher  language.Matcher
+	resolved map[language.Tag]*registryEntry
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		entries:  make(map[language.Tag]*registryEntry),
+		resolved: make(map[language.Tag]*registryEntry),
+	}
+}
+
+// Register registers a loader for the rule package of a language. The loader is called the first
+// time the language is requested. An existing registration for the tag is replaced.
+func (r *Registry) Register(tag language.Tag, load Loader) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.entries[tag]; !ok {
+		r.tags = append(r.tags, tag)
+	}
+	r.entries[tag] = &registryEntry{tag: tag, load: load}
+	r.matcher = nil
+	r.resolved = make(map[language.Tag]*registryEntry)
+}
+
+// Add registers a loaded rule package, using the package language as its tag
+func (r *Registry) Add(pkg RulePackage) {
+	r.Register(pkg.Language.Tag(), func() (RulePackage, error) { return pkg, nil })
+}
+
+// Tags returns the registered language tags, in registration order
+func (r *Registry) Tags() []language.Tag {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]language.Tag{}, r.tags...)
+}
+
+// resolve returns the registry entry best matching the tag
+func (r *Registry) resolve(tag language.Tag) (*registryEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.resolved[tag]; ok {
+		return e, nil
+	}
+	if len(r.tags) == 0 {
+		return nil, fmt.Errorf("no rule packages registered")
+	}
+	res := r.entries[tag]
+	// follow the parent locales, but don't fall back on a package registered for the root locale
+	for t := tag.Parent(); res == nil && !t.IsRoot(); t = t.Parent() {
+		res = r.entries[t]
+	}
+	if res == nil {
+		if r.matcher == nil {
+			r.matcher = language.NewMatcher(r.tags)
+		}
+		_, index, conf := r.matcher.Match(tag)
+		if conf == language.No {
+			return nil, fmt.Errorf("no rule package for language %s", tag)
+		}
+		res = r.entries[r.tags[index]]
+	}
+	r.resolved[tag] = res
+	return res, nil
+}
+
+// Match returns the registered tag used for the requested tag
+func (r *Registry) Match(tag language.Tag) (language.Tag, error) {
+	e, err := r.resolve(tag)
+	if err != nil {
+		return language.Und, err
+	}
+	return e.tag, nil
+}
+
+// Package returns the rule package best matching the tag, loading it if needed
+func (r *Registry) Package(tag language.Tag) (*RulePackage, error) {
+	e, err := r.resolve(tag)
+	if err != nil {
+		return nil, err
+	}
+	return e.get()
+}
+
+// Spellout spells out the input string using the package best matching the tag, and the named rule set group and rule set
+func (r *Registry) Spellout(tag language.Tag, input string, groupName string, ruleSetName string, debug bool) (string, error) {
+	pkg, err := r.Package(tag)
+	if err != nil {
+		return "", err
+	}
+	return pkg.Spellout(input, groupName, ruleSetName, debug)
+}
+
+// SpelloutKind spells out the input string using the package best matching the tag, and its rule set for the numeral kind
+func (r *Registry) SpelloutKind(tag language.Tag, input string, kind Kind, debug bool) (string, error) {
+	pkg, err := r.Package(tag)
+	if err != nil {
+		return "", err
+	}
+	return pkg.SpelloutKind(input, kind, debug)
+}
@@ -0,0 +1,151 @@
+package rbnf
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+// testRegistryPackage returns a package spelling out any number as the language name
+func testRegistryPackage(lang Language) (RulePackage, error) {
+	rs := RuleSet{
+		Name:  "spellout-numbering",
+		Rules: []BaseRule{NewIntRule(lang, 0, 10, string(lang))},
+	}
+	g, err := NewRuleSetGroup("SpelloutRules", lang, []RuleSet{rs})
+	if err != nil {
+		return RulePackage{}, err
+	}
+	return NewRulePackage(lang, []RuleSetGroup{g}, false)
+}
+
+func TestRegistry(t *testing.T) {
+	reg := NewRegistry()
+	if _, err := reg.Package(language.German); err == nil {
+		t.Error("expected error for empty registry")
+	}
+
+	loads := make(map[string]int)
+	var mu sync.Mutex
+	for _, lang := range []Language{"de", "sr", "sr_Latn", "zh", "zh_Hant", "en", "en_001", "nb", "fi"} {
+		lang := lang
+		reg.Register(lang.Tag(), func() (RulePackage, error) {
+			mu.Lock()
+			loads[string(lang)]++
+			mu.Unlock()
+			if lang == "fi" {
+				return RulePackage{}, fmt.Errorf("load failed")
+			}
+			return testRegistryPackage(lang)
+		})
+	}
+	sv, err := testRegistryPackage("sv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg.Add(sv)
+
+	tests := []struct {
+		tag string
+		exp string
+	}{
+		{"de", "de"},
+		{"de-AT", "de"},
+		{"sr-Latn-RS", "sr_Latn"},
+		{"sr-Cyrl-RS", "sr"},
+		{"sr-RS", "sr"},
+		{"zh-Hant-HK", "zh_Hant"},
+		{"zh-HK", "zh_Hant"},
+		{"zh-CN", "zh"},
+		{"en-US", "en"},
+		{"en-IN", "en_001"},
+		{"nn", "nb"},
+		{"sv-FI", "sv"},
+	}
+	for _, test := range tests {
+		res, err := reg.Spellout(language.MustParse(test.tag), "1", "SpelloutRules", "spellout-numbering", false)
+		if err != nil {
+			t.Errorf("%s : %v", test.tag, err)
+		} else if res != test.exp {
+			t.Errorf("%s : "+fs, test.tag, test.exp, res)
+		}
+	}
+
+	if res, err := reg.SpelloutKind(language.MustParse("de-CH"), "1", Cardinal, false); err != nil {
+		t.Error(err)
+	} else if exp := "de"; res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	if tag, err := reg.Match(language.MustParse("de-AT")); err != nil {
+		t.Error(err)
+	} else if exp := language.German; tag != exp {
+		t.Errorf(fs, exp, tag)
+	}
+
+	for _, tag := range []string{"ja", "fi"} {
+		if res, err := reg.Package(language.Make(tag)); err == nil {
+			t.Errorf("expected error for %s, got %v", tag, res.Language)
+		}
+	}
+
+	// packages are loaded once, on first use
+	if exp, res := 1, loads["de"]; exp != res {
+		t.Errorf(fs, exp, res)
+	}
+	if exp, res := 1, loads["zh"]; exp != res {
+		t.Errorf(fs, exp, res)
+	}
+	if _, ok := loads["sv"]; ok {
+		t.Error("sv is not registered with a loader")
+	}
+	if exp, res := 10, len(reg.Tags()); exp != res {
+		t.Errorf(fs, exp, res)
+	}
+
+	// failed loads are not cached
+	if _, err := reg.Package(language.Finnish); err == nil {
+		t.Error("expected error for fi")
+	}
+	if exp, res := 2, loads["fi"]; exp != res {
+		t.Errorf(fs, exp, res)
+	}
+	failures := 1
+	reg.Register(language.Finnish, func() (RulePackage, error) {
+		if failures > 0 {
+			failures--
+			return RulePackage{}, fmt.Errorf("transient failure")
+		}
+		return testRegistryPackage("fi")
+	})
+	if _, err := reg.Package(language.Finnish); err == nil {
+		t.Error("expected error for fi")
+	}
+	if res, err := reg.Spellout(language.Finnish, "1", "SpelloutRules", "spellout-numbering", false); err != nil {
+		t.Error(err)
+	} else if exp := "fi"; res != exp {
+		t.Errorf(fs, exp, res)
+	}
+}
+
+func TestRegistryConcurrent(t *testing.T) {
+	reg := NewRegistry()
+	for _, lang := range []Language{"de", "fr", "sv"} {
+		lang := lang
+		reg.Register(lang.Tag(), func() (RulePackage, error) { return testRegistryPackage(lang) })
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tag := []string{"de-AT", "fr-CA", "sv-FI"}[i%3]
+			if _, err := reg.SpelloutKind(language.MustParse(tag), "3", Cardinal, false); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,137 @@
+package rbnf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind is a language independent name for a type of numeral, used to select the rule set that implements it for a given language
+type Kind string
+
+const (
+	Cardinal      Kind = "cardinal"
+	Ordinal       Kind = "ordinal"
+	Year          Kind = "year"
+	DigitsOrdinal Kind = "digits-ordinal"
+)
+
+// Kinds lists the supported numeral kinds
+var Kinds = []Kind{Cardinal, Ordinal, Year, DigitsOrdinal}
+
+// ParseKind returns the Kind for a string (cardinal, ordinal, year or digits-ordinal)
+func ParseKind(s string) (Kind, error) {
+	for _, k := range Kinds {
+		if string(k) == s {
+			return k, nil
+		}
+	}
+	return "", fmt.Errorf("unknown numeral kind: %s", s)
+}
+
+// rule set names used for languages without an entry in languageRuleSetNames
+var defaultRuleSetNames = map[Kind]string{
+	Cardinal:      "spellout-numbering",
+	Ordinal:       "spellout-ordinal",
+	Year:          "spellout-numbering-year",
+	DigitsOrdinal: "digits-ordinal",
+}
+
+// language specific rule set names, cf comp-icu4j-vs-rbnfgo/batch_run_*.sh
+var languageRuleSetNames = map[Language]map[Kind]string{
+	"ar":    {Ordinal: "spellout-ordinal-feminine"},
+	"be":    {Ordinal: "spellout-ordinal-feminine"},
+	"ca":    {Ordinal: "spellout-ordinal-feminine"},
+	"da":    {Cardinal: "spellout-cardinal-common", Ordinal: "spellout-ordinal-common"},
+	"el":    {Ordinal: "spellout-ordinal-feminine"},
+	"es":    {Ordinal: "spellout-ordinal-feminine"},
+	"fr":    {Ordinal: "spellout-ordinal-feminine"},
+	"fr_BE": {Ordinal: "spellout-ordinal-feminine"},
+	"fr_CH": {Ordinal: "spellout-ordinal-feminine"},
+	"he":    {Ordinal: "spellout-ordinal-feminine"},
+	"hi":    {Ordinal: "spellout-ordinal-feminine"},
+	"hr":    {Ordinal: "spellout-ordinal-feminine"},
+	"it":    {Ordinal: "spellout-ordinal-feminine"},
+	"ko":    {Ordinal: "spellout-ordinal-native"},
+	"lb":    {Ordinal: "spellout-ordinal-feminine"},
+	"nb":    {Ordinal: "spellout-ordinal-feminine"},
+	"pt":    {Ordinal: "spellout-ordinal-feminine"},
+	"pt_PT": {Ordinal: "spellout-ordinal-feminine"},
+	"ru":    {Ordinal: "spellout-ordinal-feminine"},
+	"sv":    {Ordinal: "spellout-ordinal-feminine"},
+}
+
+// DefaultRuleSetName returns the name of the built-in rule set for a language and numeral kind.
+// Regional variants (such as de_CH or de-CH) fall back on their base language.
+func DefaultRuleSetName(lang Language, kind Kind) (string, bool) {
+	l := strings.Replace(string(lang), "-", "_", -1)
+	for {
+		if name, ok := languageRuleSetNames[Language(l)][kind]; ok {
+			return name, true
+		}
+		i := strings.LastIndex(l, "_")
+		if i < 0 {
+			break
+		}
+		l = l[:i]
+	}
+	name, ok := defaultRuleSetNames[kind]
+	return name, ok
+}
+
+// findGroupWithRuleSet returns the name of the first rule set group containing the named rule set
+func (r *RulePackage) findGroupWithRuleSet(ruleSetName string) (string, bool) {
+	for _, g := range r.RuleSetGroups {
+		if _, ok := g.FindRuleSet(ruleSetName); ok {
+			return g.Name, true
+		}
+	}
+	return "", false
+}
+
+// RuleSetFor returns the rule set group and rule set used for the numeral kind. Names set in
+// RulePackage.RuleSetNames override the built-in table (see DefaultRuleSetName).
+func (r *RulePackage) RuleSetFor(kind Kind) (string, string, error) {
+	if name, ok := r.RuleSetNames[kind]; ok {
+		if groupName, ok := r.findGroupWithRuleSet(name); ok {
+			return groupName, name, nil
+		}
+		return "", "", fmt.Errorf("no such rule set for %s: %s", kind, name)
+	}
+	name, ok := DefaultRuleSetName(r.Language, kind)
+	if !ok {
+		return "", "", fmt.Errorf("unknown numeral kind: %s", kind)
+	}
+	if groupName, ok := r.findGroupWithRuleSet(name); ok {
+		return groupName, name, nil
+	}
+	return "", "", fmt.Errorf("no rule set for %s in language %s (tried %s)", kind, r.Language, name)
+}
+
+// SpelloutKind spells out the input string using the rule set for the numeral kind
+func (r *RulePackage) SpelloutKind(input string, kind Kind, debug bool) (string, error) {
+	groupName, ruleSetName, err := r.RuleSetFor(kind)
+	if err != nil {
+		return "", err
+	}
+	return r.Spellout(input, groupName, ruleSetName, debug)
+}
+
+// SpelloutCardinal spells out the input string as a plain cardinal number
+func (r *RulePackage) SpelloutCardinal(input string, debug bool) (string, error) {
+	return r.SpelloutKind(input, Cardinal, debug)
+}
+
+// SpelloutOrdinal spells out the input string as an ordinal number
+func (r *RulePackage) SpelloutOrdinal(input string, debug bool) (string, error) {
+	return r.SpelloutKind(input, Ordinal, debug)
+}
+
+// SpelloutYear spells out the input string as a year
+func (r *RulePackage) SpelloutYear(input string, debug bool) (string, error) {
+	return r.SpelloutKind(input, Year, debug)
+}
+
+// DigitsOrdinal formats the input string as an ordinal number written in digits (such as 2nd or 2:a)
+func (r *RulePackage) DigitsOrdinal(input string, debug bool) (string, error) {
+	return r.SpelloutKind(input, DigitsOrdinal, debug)
+}
@@ -0,0 +1,34 @@
+package rbnf
+
+import (
+	"testing"
+)
+
+func TestDefaultRuleSetName(t *testing.T) {
+	tests := []struct {
+		lang Language
+		kind Kind
+		exp  string
+	}{
+		{"en", Cardinal, "spellout-numbering"},
+		{"da", Cardinal, "spellout-cardinal-common"},
+		{"da", Ordinal, "spellout-ordinal-common"},
+		{"fr_CA", Ordinal, "spellout-ordinal-feminine"},
+		{"pt-PT", Ordinal, "spellout-ordinal-feminine"},
+		{"de", Ordinal, "spellout-ordinal"},
+		{"de_CH", Year, "spellout-numbering-year"},
+		{"sv", DigitsOrdinal, "digits-ordinal"},
+	}
+	for _, test := range tests {
+		res, ok := DefaultRuleSetName(test.lang, test.kind)
+		if !ok {
+			t.Errorf("no rule set name for %s %s", test.lang, test.kind)
+		} else if res != test.exp {
+			t.Errorf(fs, test.exp, res)
+		}
+	}
+
+	if _, ok := DefaultRuleSetName("en", Kind("dual")); ok {
+		t.Error("expected failure here")
+	}
+}
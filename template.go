@@ -0,0 +1,127 @@
+package rbnf
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// templateRuleSet is the cache key for a rule set lookup: either a numeral kind or a rule set name, for a package
+// (packages of the same language may have different rule set groups, or overlays applied)
+type templateRuleSet struct {
+	pkg     *RulePackage
+	kind    Kind
+	ruleSet string
+}
+
+type templateFuncs struct {
+	pkgs []*RulePackage
+
+	mu       sync.Mutex
+	langs    map[string]*RulePackage
+	ruleSets map[templateRuleSet][2]string
+}
+
+// FuncMap returns spellout functions for text/template and html/template, backed by the rule packages:
+//
+//	{{ spellout .Count "sv" "spellout-numbering" }}  named rule set
+//	{{ cardinal .Count }}                           cardinal number
+//	{{ ordinal .Rank "sv" }}                        ordinal number
+//	{{ year .Year }}                                year
+//	{{ digitsOrdinal .Rank }}                       ordinal number written in digits
+//
+// The language argument is optional for all functions but spellout, and defaults to the language of the
+// first package. Languages are matched by exact name, and then by base language (sv-SE and sv_SE match sv).
+// Numbers can be any Go integer or float type, math/big numbers, or strings (see RulePackage.Number).
+// Errors are returned as template execution errors. Rule set lookups are cached, so the packages should
+// not be modified after the FuncMap is created.
+//
+// The result can be passed to Funcs of both template packages, since their FuncMap types have the same
+// underlying type.
+func FuncMap(pkgs ...*RulePackage) map[string]interface{} {
+	f := &templateFuncs{
+		pkgs:     pkgs,
+		langs:    make(map[string]*RulePackage),
+		ruleSets: make(map[templateRuleSet][2]string),
+	}
+	kindFunc := func(kind Kind) func(interface{}, ...string) (string, error) {
+		return func(n interface{}, lang ...string) (string, error) {
+			if len(lang) > 1 {
+				return "", fmt.Errorf("%s: too many arguments", kind)
+			}
+			return f.spellout(n, strings.Join(lang, ""), kind, "")
+		}
+	}
+	return map[string]interface{}{
+		"spellout": func(n interface{}, lang string, ruleSet string) (string, error) {
+			return f.spellout(n, lang, "", ruleSet)
+		},
+		"cardinal":      kindFunc(Cardinal),
+		"ordinal":       kindFunc(Ordinal),
+		"year":          kindFunc(Year),
+		"digitsOrdinal": kindFunc(DigitsOrdinal),
+	}
+}
+
+// pkg returns the rule package for a language name (the first package if the name is empty)
+func (f *templateFuncs) pkg(lang string) (*RulePackage, error) {
+	if len(f.pkgs) == 0 {
+		return nil, fmt.Errorf("no rule packages")
+	}
+	if lang == "" {
+		return f.pkgs[0], nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if p, ok := f.langs[lang]; ok {
+		return p, nil
+	}
+	normalized := strings.Replace(lang, "-", "_", -1)
+	for _, name := range []string{lang, normalized, strings.Split(normalized, "_")[0]} {
+		for _, p := range f.pkgs {
+			if strings.EqualFold(string(p.Language), name) {
+				f.langs[lang] = p
+				return p, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no rule package for language %s", lang)
+}
+
+// ruleSet returns the cached rule set group and rule set for a numeral kind or rule set name
+func (f *templateFuncs) ruleSet(p *RulePackage, kind Kind, ruleSet string) (string, string, error) {
+	key := templateRuleSet{pkg: p, kind: kind, ruleSet: ruleSet}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if res, ok := f.ruleSets[key]; ok {
+		return res[0], res[1], nil
+	}
+	var groupName string
+	if ruleSet == "" {
+		var err error
+		groupName, ruleSet, err = p.RuleSetFor(kind)
+		if err != nil {
+			return "", "", err
+		}
+	} else {
+		var ok bool
+		groupName, ok = p.findGroupWithRuleSet(ruleSet)
+		if !ok {
+			return "", "", fmt.Errorf("no such rule set for language %s: %s", p.Language, ruleSet)
+		}
+	}
+	f.ruleSets[key] = [2]string{groupName, ruleSet}
+	return groupName, ruleSet, nil
+}
+
+func (f *templateFuncs) spellout(n interface{}, lang string, kind Kind, ruleSet string) (string, error) {
+	p, err := f.pkg(lang)
+	if err != nil {
+		return "", err
+	}
+	groupName, ruleSetName, err := f.ruleSet(p, kind, ruleSet)
+	if err != nil {
+		return "", err
+	}
+	return p.Number(n).Group(groupName).RuleSet(ruleSetName).Spellout()
+}
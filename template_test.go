@@ -0,0 +1,72 @@
+package rbnf_test
+
+import (
+	htmltemplate "html/template"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stts-se/rbnf"
+)
+
+func TestFuncMap(t *testing.T) {
+	en := testPackage(t, "en")
+	sv := testPackage(t, "sv")
+
+	funcs := rbnf.FuncMap(&en, &sv)
+	data := map[string]interface{}{"Count": 2, "Rank": 3}
+
+	tests := []struct {
+		tmpl string
+		exp  string
+	}{
+		{`{{ spellout .Count "sv" "spellout-numbering" }}`, "två"},
+		{`{{ spellout .Count "sv-SE" "spellout-numbering" }}`, "två"},
+		{`{{ ordinal .Rank }}`, "third"},
+		{`{{ ordinal .Rank "sv" }}`, "tredje"},
+		{`{{ cardinal .Count }} {{ cardinal .Count "sv" }}`, "two två"},
+		{`{{ spellout 2.5 "en" "spellout-cardinal" }}`, "two point five"},
+		{`{{ cardinal "3" }}`, "three"},
+	}
+	for _, test := range tests {
+		tmpl, err := template.New("test").Funcs(funcs).Parse(test.tmpl)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		var res strings.Builder
+		if err := tmpl.Execute(&res, data); err != nil {
+			t.Error(err)
+		} else if res.String() != test.exp {
+			t.Errorf(fs, test.exp, res.String())
+		}
+	}
+
+	for _, tmplString := range []string{
+		`{{ spellout .Count "de" "spellout-numbering" }}`,
+		`{{ spellout .Count "en" "spellout-cardinal-feminine" }}`,
+		`{{ ordinal .Rank "en" "sv" }}`,
+	} {
+		tmpl, err := template.New("test").Funcs(funcs).Parse(tmplString)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		var res strings.Builder
+		if err := tmpl.Execute(&res, data); err == nil {
+			t.Errorf("expected error for %s, got %s", tmplString, res.String())
+		}
+	}
+
+	// html/template
+	tmpl, err := htmltemplate.New("test").Funcs(funcs).Parse(`<b>{{ ordinal .Rank }}</b>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res strings.Builder
+	if exp, err := "<b>third</b>", tmpl.Execute(&res, data); err != nil {
+		t.Error(err)
+	} else if res.String() != exp {
+		t.Errorf(fs, exp, res.String())
+	}
+}
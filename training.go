@@ -0,0 +1,343 @@
+package rbnf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Distribution is a distribution of numbers to sample training inputs from (see RulePackage.TrainingData)
+type Distribution interface {
+	// Name is the name of the distribution, included in the training examples
+	Name() string
+	// Sample returns a number from the distribution, as rule input (ASCII digits, with . as decimal separator)
+	Sample(rnd *rand.Rand) string
+}
+
+type uniformInts struct {
+	name   string
+	lo, hi int64
+}
+
+func (d uniformInts) Name() string {
+	return d.name
+}
+
+func (d uniformInts) Sample(rnd *rand.Rand) string {
+	// the range size hi-lo+1 doesn't fit in an int64 for the widest ranges
+	span := uint64(d.hi) - uint64(d.lo)
+	if span < uint64(maxInt64) {
+		return strconv.FormatInt(d.lo+rnd.Int63n(int64(span)+1), 10)
+	}
+	for {
+		if n := rnd.Uint64(); n <= span {
+			return strconv.FormatInt(int64(uint64(d.lo)+n), 10)
+		}
+	}
+}
+
+// orderedRange returns the range limits in increasing order
+func orderedRange(lo, hi int64) (int64, int64) {
+	if hi < lo {
+		return hi, lo
+	}
+	return lo, hi
+}
+
+// UniformInts returns a distribution of the integers lo..hi, with equal probability. If hi is less than lo,
+// the limits are swapped.
+func UniformInts(lo, hi int64) Distribution {
+	lo, hi = orderedRange(lo, hi)
+	return uniformInts{name: fmt.Sprintf("uniform:%d:%d", lo, hi), lo: lo, hi: hi}
+}
+
+// Years returns a distribution of the years lo..hi, with equal probability. If hi is less than lo, the
+// limits are swapped.
+func Years(lo, hi int64) Distribution {
+	lo, hi = orderedRange(lo, hi)
+	return uniformInts{name: fmt.Sprintf("year:%d:%d", lo, hi), lo: lo, hi: hi}
+}
+
+// maxDigits is the maximum number of digits of the distributions (the number of digits of an int64)
+const maxDigits = 18
+
+// clampDigits returns n limited to min..maxDigits
+func clampDigits(n, min int) int {
+	if n < min {
+		return min
+	}
+	if n > maxDigits {
+		return maxDigits
+	}
+	return n
+}
+
+// sampleDigits returns a number of minDigits..maxDigits digits, with equal probability for each number of digits
+func sampleDigits(rnd *rand.Rand, minDigits, maxDigits int) string {
+	n := minDigits + rnd.Intn(maxDigits-minDigits+1)
+	if n == 1 {
+		return strconv.Itoa(rnd.Intn(10))
+	}
+	var b strings.Builder
+	b.WriteByte(byte('1' + rnd.Intn(9)))
+	for i := 1; i < n; i++ {
+		b.WriteByte(byte('0' + rnd.Intn(10)))
+	}
+	return b.String()
+}
+
+type logUniformInts struct {
+	minDigits, maxDigits int
+}
+
+func (d logUniformInts) Name() string {
+	return fmt.Sprintf("loguniform:%d:%d", d.minDigits, d.maxDigits)
+}
+
+func (d logUniformInts) Sample(rnd *rand.Rand) string {
+	return sampleDigits(rnd, d.minDigits, d.maxDigits)
+}
+
+// LogUniformInts returns a distribution of integers with minDigits..maxDigits digits, where each magnitude (number
+// of digits) has equal probability, and the numbers of a magnitude have equal probability. The numbers of digits
+// are clamped to 1..18, and maxDigits to at least minDigits.
+func LogUniformInts(minDigits, maxDigits int) Distribution {
+	minDigits = clampDigits(minDigits, 1)
+	return logUniformInts{minDigits: minDigits, maxDigits: clampDigits(maxDigits, minDigits)}
+}
+
+type decimals struct {
+	maxIntDigits, fractionDigits int
+}
+
+func (d decimals) Name() string {
+	return fmt.Sprintf("decimal:%d:%d", d.maxIntDigits, d.fractionDigits)
+}
+
+func (d decimals) Sample(rnd *rand.Rand) string {
+	var b strings.Builder
+	b.WriteString(sampleDigits(rnd, 1, d.maxIntDigits))
+	b.WriteByte('.')
+	for i := 0; i < d.fractionDigits; i++ {
+		b.WriteByte(byte('0' + rnd.Intn(10)))
+	}
+	return b.String()
+}
+
+// Decimals returns a distribution of decimal numbers with exactly fractionDigits fraction digits, and an integer
+// part of 1..maxIntDigits digits (log-uniform, see LogUniformInts). The numbers of digits are clamped to 1..18.
+func Decimals(maxIntDigits, fractionDigits int) Distribution {
+	return decimals{maxIntDigits: clampDigits(maxIntDigits, 1), fractionDigits: clampDigits(fractionDigits, 1)}
+}
+
+type negatives struct {
+	d Distribution
+}
+
+func (d negatives) Name() string {
+	return "negative:" + d.d.Name()
+}
+
+func (d negatives) Sample(rnd *rand.Rand) string {
+	s := strings.TrimPrefix(d.d.Sample(rnd), "-")
+	if strings.Trim(s, "0.") == "" {
+		return s
+	}
+	return "-" + s
+}
+
+// Negatives returns the negated numbers of a distribution (zero is not negated)
+func Negatives(d Distribution) Distribution {
+	return negatives{d: d}
+}
+
+// ParseDistribution parses a distribution from its name: uniform:<lo>:<hi>, year:<lo>:<hi>,
+// loguniform:<min digits>:<max digits>, decimal:<max int digits>:<fraction digits> or negative:<distribution>
+func ParseDistribution(s string) (Distribution, error) {
+	fields := strings.Split(s, ":")
+	if fields[0] == "negative" {
+		d, err := ParseDistribution(strings.TrimPrefix(s, "negative:"))
+		if err != nil {
+			return nil, err
+		}
+		return Negatives(d), nil
+	}
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("invalid distribution: %s", s)
+	}
+	a, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid distribution %s : %v", s, err)
+	}
+	b, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid distribution %s : %v", s, err)
+	}
+	switch fields[0] {
+	case "uniform", "year":
+		if a > b {
+			return nil, fmt.Errorf("invalid range for distribution: %s", s)
+		}
+		if fields[0] == "year" {
+			return Years(a, b), nil
+		}
+		return UniformInts(a, b), nil
+	case "loguniform":
+		if a < 1 || a > b || b > maxDigits {
+			return nil, fmt.Errorf("invalid number of digits for distribution (1-18): %s", s)
+		}
+		return LogUniformInts(int(a), int(b)), nil
+	case "decimal":
+		if a < 1 || a > maxDigits || b < 1 || b > maxDigits {
+			return nil, fmt.Errorf("invalid number of digits for distribution (1-18): %s", s)
+		}
+		return Decimals(int(a), int(b)), nil
+	}
+	return nil, fmt.Errorf("unknown distribution: %s", s)
+}
+
+const maxInt64 = int64(^uint64(0) >> 1)
+
+// DefaultDistributions are the distributions used by RulePackage.TrainingData if none are specified
+var DefaultDistributions = []Distribution{
+	UniformInts(0, 100),
+	LogUniformInts(1, 12),
+	Years(1000, 2100),
+	Decimals(3, 2),
+	Negatives(LogUniformInts(1, 6)),
+}
+
+// TrainingExample is a number spelled out by a rule set, with metadata
+type TrainingExample struct {
+	Input        string `json:"input"`
+	Output       string `json:"output"`
+	Language     string `json:"language"`
+	Group        string `json:"group"`
+	RuleSet      string `json:"ruleSet"`
+	Distribution string `json:"distribution"`
+}
+
+type trainingOptions struct {
+	ruleSets      []string
+	distributions []Distribution
+	seed          int64
+	maxAttempts   int
+}
+
+// TrainingOption is an option for RulePackage.TrainingData
+type TrainingOption func(*trainingOptions)
+
+// TrainingRuleSets sets the rule sets to spell out with, in any rule set group (default all public rule sets)
+func TrainingRuleSets(names ...string) TrainingOption {
+	return func(o *trainingOptions) { o.ruleSets = names }
+}
+
+// TrainingDistributions sets the distributions to sample numbers from (default DefaultDistributions).
+// For each example, a distribution is selected at random, with equal probability.
+func TrainingDistributions(dists ...Distribution) TrainingOption {
+	return func(o *trainingOptions) { o.distributions = dists }
+}
+
+// TrainingSeed sets the random seed (default 1). The same seed gives the same examples for the same package and options.
+func TrainingSeed(seed int64) TrainingOption {
+	return func(o *trainingOptions) { o.seed = seed }
+}
+
+// TrainingMaxAttempts sets the maximum number of sampled numbers per rule set, including those filtered out (default 10 times the number of examples)
+func TrainingMaxAttempts(n int) TrainingOption {
+	return func(o *trainingOptions) { o.maxAttempts = n }
+}
+
+// TrainingData samples n numbers for each rule set, and spells them out. Numbers that the rule set can't
+// handle are filtered out: numbers without a matching rule, and numbers with output containing digits
+// (from numeric formatters, such as =#,##0=). The package fallback policies are not applied. If too many
+// numbers are filtered out, a rule set gets fewer than n examples (see TrainingMaxAttempts).
+// Examples are in order of rule set group and rule set name.
+func (r *RulePackage) TrainingData(n int, opts ...TrainingOption) ([]TrainingExample, error) {
+	o := trainingOptions{distributions: DefaultDistributions, seed: 1, maxAttempts: 10 * n}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if len(o.distributions) == 0 {
+		return nil, fmt.Errorf("no distributions for training data")
+	}
+	rnd := rand.New(rand.NewSource(o.seed))
+
+	res := []TrainingExample{}
+	found := make(map[string]bool)
+	for _, g := range r.RuleSetGroups {
+		names := []string{}
+		for _, rs := range g.RuleSets {
+			if len(o.ruleSets) == 0 && !rs.Private || containsString(o.ruleSets, rs.Name) {
+				names = append(names, rs.Name)
+				found[rs.Name] = true
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			count := 0
+			for i := 0; i < o.maxAttempts && count < n; i++ {
+				d := o.distributions[rnd.Intn(len(o.distributions))]
+				input := d.Sample(rnd)
+				output, err := g.Spellout(input, name, r.Debug)
+				if err != nil || strings.ContainsAny(output, "0123456789") {
+					continue
+				}
+				res = append(res, TrainingExample{
+					Input:        input,
+					Output:       output,
+					Language:     string(r.Language),
+					Group:        g.Name,
+					RuleSet:      name,
+					Distribution: d.Name(),
+				})
+				count++
+			}
+		}
+	}
+	for _, name := range o.ruleSets {
+		if !found[name] {
+			return nil, fmt.Errorf("no such rule set: %s", name)
+		}
+	}
+	return res, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteTrainingTSV writes training examples as tab-separated values, with a header line:
+// input, output, language, group, rule set and distribution
+func WriteTrainingTSV(w io.Writer, examples []TrainingExample) error {
+	if _, err := fmt.Fprintln(w, "input\toutput\tlanguage\tgroup\truleSet\tdistribution"); err != nil {
+		return err
+	}
+	for _, e := range examples {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Input, e.Output, e.Language, e.Group, e.RuleSet, e.Distribution); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteTrainingJSONL writes training examples as JSON lines, one object per example
+func WriteTrainingJSONL(w io.Writer, examples []TrainingExample) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, e := range examples {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
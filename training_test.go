@@ -0,0 +1,160 @@
+package rbnf
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDistributions(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		if s := UniformInts(-5, 5).Sample(rnd); len(s) > 2 {
+			t.Errorf("uniform sample out of range: %s", s)
+		}
+		if s := Years(1000, 2100).Sample(rnd); s < "1000" || s > "2100" || len(s) != 4 {
+			t.Errorf("year sample out of range: %s", s)
+		}
+		if s := LogUniformInts(2, 4).Sample(rnd); len(s) < 2 || len(s) > 4 || s[0] == '0' {
+			t.Errorf("loguniform sample out of range: %s", s)
+		}
+		if s := Decimals(3, 2).Sample(rnd); !strings.Contains(s, ".") || len(strings.Split(s, ".")[1]) != 2 {
+			t.Errorf("invalid decimal sample: %s", s)
+		}
+		if s := Negatives(UniformInts(1, 9)).Sample(rnd); !strings.HasPrefix(s, "-") {
+			t.Errorf("invalid negative sample: %s", s)
+		}
+	}
+	if s := Negatives(UniformInts(0, 0)).Sample(rnd); s != "0" {
+		t.Errorf(fs, "0", s)
+	}
+
+	// invalid ranges are swapped or clamped by the constructors
+	for _, test := range []struct {
+		d    Distribution
+		name string
+	}{
+		{UniformInts(5, -5), "uniform:-5:5"},
+		{Years(2100, 1000), "year:1000:2100"},
+		{UniformInts(-maxInt64-1, maxInt64), "uniform:-9223372036854775808:9223372036854775807"},
+		{LogUniformInts(4, 2), "loguniform:4:4"},
+		{LogUniformInts(-1, 30), "loguniform:1:18"},
+		{Decimals(0, -2), "decimal:1:1"},
+	} {
+		if res := test.d.Name(); res != test.name {
+			t.Errorf(fs, test.name, res)
+		}
+		for i := 0; i < 100; i++ {
+			s := test.d.Sample(rnd)
+			if _, err := strconv.ParseFloat(s, 64); err != nil {
+				t.Errorf("invalid sample for %s: %s", test.name, s)
+			}
+		}
+	}
+	if s := UniformInts(5, -5).Sample(rnd); len(s) > 2 {
+		t.Errorf("uniform sample out of range: %s", s)
+	}
+	if s := LogUniformInts(4, 2).Sample(rnd); len(s) != 4 {
+		t.Errorf("loguniform sample out of range: %s", s)
+	}
+
+	for _, s := range []string{"uniform:0:100", "year:1000:2100", "loguniform:1:12", "decimal:3:2", "negative:loguniform:1:6"} {
+		d, err := ParseDistribution(s)
+		if err != nil {
+			t.Error(err)
+		} else if d.Name() != s {
+			t.Errorf(fs, s, d.Name())
+		}
+	}
+	for _, s := range []string{"", "uniform", "uniform:1", "uniform:9:1", "loguniform:0:3", "loguniform:1:19", "decimal:0:2", "decimal:1:x", "gaussian:1:2", "negative:"} {
+		if _, err := ParseDistribution(s); err == nil {
+			t.Errorf("expected error for distribution %s", s)
+		}
+	}
+}
+
+func TestTrainingData(t *testing.T) {
+	g := testGroup(t)
+	pack, err := NewRulePackage(g.Language, []RuleSetGroup{g}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dists := TrainingDistributions(UniformInts(0, 500), Negatives(UniformInts(1, 3)))
+	examples, err := pack.TrainingData(50, dists, TrainingSeed(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, res := 50, len(examples); res != exp {
+		t.Errorf(fs, exp, res)
+	}
+	for _, e := range examples {
+		if e.Language != "sv" || e.Group != "SpelloutRules" || e.RuleSet != "spellout-numbering" {
+			t.Errorf("unexpected metadata: %#v", e)
+		}
+		// the numeric formatter rules (4-19, 30-99 and 400-) are filtered out
+		if n, err := strconv.Atoi(e.Input); err != nil || n >= 400 || n > 3 && n < 20 {
+			t.Errorf("unexpected input: %#v", e)
+		}
+		if exp, err := g.Spellout(e.Input, e.RuleSet, false); err != nil {
+			t.Error(err)
+		} else if e.Output != exp {
+			t.Errorf(fs, exp, e.Output)
+		}
+	}
+
+	again, err := pack.TrainingData(50, dists, TrainingSeed(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(examples, again) {
+		t.Error("expected same examples for same seed")
+	}
+	other, err := pack.TrainingData(50, dists, TrainingSeed(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(examples, other) {
+		t.Error("expected different examples for different seeds")
+	}
+
+	// no input can be spelled out: fewer examples than requested
+	few, err := pack.TrainingData(10, TrainingDistributions(UniformInts(1000, 2000)))
+	if err != nil {
+		t.Error(err)
+	} else if len(few) != 0 {
+		t.Errorf(fs, 0, len(few))
+	}
+
+	if _, err := pack.TrainingData(10, TrainingRuleSets("spellout-ordinal")); err == nil {
+		t.Error("expected error for missing rule set")
+	}
+	if hundreds, err := pack.TrainingData(10, TrainingRuleSets("hundreds"), TrainingDistributions(UniformInts(1, 2))); err != nil {
+		t.Error(err)
+	} else if len(hundreds) != 10 || hundreds[0].RuleSet != "hundreds" {
+		t.Errorf("unexpected examples: %#v", hundreds)
+	}
+}
+
+func TestWriteTraining(t *testing.T) {
+	examples := []TrainingExample{
+		{Input: "21", Output: "tjugoett", Language: "sv", Group: "SpelloutRules", RuleSet: "spellout-numbering", Distribution: "uniform:0:100"},
+	}
+	var tsv strings.Builder
+	if err := WriteTrainingTSV(&tsv, examples); err != nil {
+		t.Fatal(err)
+	}
+	exp := "input\toutput\tlanguage\tgroup\truleSet\tdistribution\n21\ttjugoett\tsv\tSpelloutRules\tspellout-numbering\tuniform:0:100\n"
+	if res := tsv.String(); res != exp {
+		t.Errorf(fs, exp, res)
+	}
+	var jsonl strings.Builder
+	if err := WriteTrainingJSONL(&jsonl, examples); err != nil {
+		t.Fatal(err)
+	}
+	exp = `{"input":"21","output":"tjugoett","language":"sv","group":"SpelloutRules","ruleSet":"spellout-numbering","distribution":"uniform:0:100"}` + "\n"
+	if res := jsonl.String(); res != exp {
+		t.Errorf(fs, exp, res)
+	}
+}
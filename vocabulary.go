@@ -0,0 +1,454 @@
+package rbnf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultVocabularyLimit is the default maximum number of compounds built for a rule set (see VocabularyLimit)
+const DefaultVocabularyLimit = 100000
+
+const maxInt = int(^uint(0) >> 1)
+
+type vocabularyOptions struct {
+	ruleSets []string
+	limit    int
+}
+
+// VocabularyOption is an option for RuleSetGroup.Vocabulary
+type VocabularyOption func(*vocabularyOptions)
+
+// VocabularyRuleSets sets the rule sets to extract the vocabulary for (default all public rule sets)
+func VocabularyRuleSets(names ...string) VocabularyOption {
+	return func(o *vocabularyOptions) { o.ruleSets = names }
+}
+
+// VocabularyLimit sets the maximum number of compounds built for any rule set and number range (default DefaultVocabularyLimit).
+// Languages writing long numbers as single words (such as Swedish, with 999999 as one word) have very large vocabularies;
+// when the limit is exceeded, the compounds are truncated, and Vocabulary.Truncated is set.
+func VocabularyLimit(n int) VocabularyOption {
+	return func(o *vocabularyOptions) { o.limit = n }
+}
+
+// OpenSub is a sub with open-ended output, such as a numeric formatter (=#,##0=)
+type OpenSub struct {
+	RuleSet string
+	Base    string
+	Sub     string
+}
+
+// Vocabulary is the set of words a rule set group can produce (see RuleSetGroup.Vocabulary)
+type Vocabulary struct {
+	// Fragments are the literal text fragments of the reachable rules (orths and plural cases), split at spaces, sorted
+	Fragments []string
+	// Words are the candidate surface words, with fragments joined without spaces combined into compounds, sorted
+	Words []string
+	// OpenWords are the candidate words containing open-ended output, with the numeric formatter pattern in braces: {#,##0}:e
+	OpenWords []string
+	// Open are the reachable subs with open-ended output
+	Open []OpenSub
+	// Truncated is set if the number of compounds exceeded the limit, so that Words is incomplete
+	Truncated bool
+}
+
+// shapes is an abstraction of a set of outputs: outputs without spaces are kept whole (atoms), and outputs
+// with spaces are represented by their first and last word parts (lefts and rights), which may be joined
+// with surrounding text. Complete words between them are collected by the vocabulary builder.
+type shapes struct {
+	atoms  map[string]bool
+	lefts  map[string]bool
+	rights map[string]bool
+}
+
+func newShapes() shapes {
+	return shapes{atoms: make(map[string]bool), lefts: make(map[string]bool), rights: make(map[string]bool)}
+}
+
+func (s shapes) empty() bool {
+	return len(s.atoms) == 0 && len(s.lefts) == 0
+}
+
+func sortedKeys(m map[string]bool) []string {
+	res := []string{}
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// inputKind is the kind of input a rule set is applied to, deciding which string rules can match
+type inputKind int
+
+const (
+	intInput     inputKind = iota // non-negative integers
+	decimalInput                  // non-negative integers and decimals (x.x, x,x, x%)
+	anyInput                      // including negative numbers (-x)
+)
+
+type vocabularyBuilder struct {
+	g         RuleSetGroup
+	limit     int
+	words     map[string]bool
+	memo      map[string]shapes
+	active    map[string]bool
+	open      map[OpenSub]bool
+	truncated bool
+}
+
+func (b *vocabularyBuilder) addWord(w string) {
+	if w != "" {
+		b.words[w] = true
+	}
+}
+
+// fill adds the strings of src to dst, keeping at most b.limit strings in dst. If strings are dropped,
+// the vocabulary is truncated. After the first truncation, sets that would exceed the limit are not
+// extended at all, since compounds beyond the limit are not reported anyway.
+func (b *vocabularyBuilder) fill(dst map[string]bool, n int, src func(yield func(string) bool)) {
+	if len(dst)+n <= b.limit {
+		src(func(s string) bool { dst[s] = true; return true })
+		return
+	}
+	if b.truncated {
+		return
+	}
+	b.truncated = true
+	src(func(s string) bool {
+		if len(dst) >= b.limit {
+			return false
+		}
+		dst[s] = true
+		return true
+	})
+}
+
+// add adds the outputs of o to s
+func (b *vocabularyBuilder) add(s, o shapes) {
+	for _, kind := range [][2]map[string]bool{{s.atoms, o.atoms}, {s.lefts, o.lefts}, {s.rights, o.rights}} {
+		dst, src := kind[0], kind[1]
+		b.fill(dst, len(src), func(yield func(string) bool) {
+			for _, k := range sortedKeys(src) {
+				if !yield(k) {
+					return
+				}
+			}
+		})
+	}
+}
+
+// text returns the shapes of a literal text
+func (b *vocabularyBuilder) text(s string) shapes {
+	res := newShapes()
+	parts := strings.Split(s, " ")
+	if len(parts) == 1 {
+		res.atoms[s] = true
+		return res
+	}
+	for _, w := range parts[1 : len(parts)-1] {
+		b.addWord(w)
+	}
+	res.lefts[parts[0]] = true
+	res.rights[parts[len(parts)-1]] = true
+	return res
+}
+
+// join returns the shapes of the outputs of x followed by the outputs of y. Words completed by the join
+// are collected, and the number of compounds is limited to b.limit.
+func (b *vocabularyBuilder) join(x, y shapes) shapes {
+	res := newShapes()
+	if x.empty() || y.empty() {
+		return res
+	}
+	product := func(dst map[string]bool, xs, ys map[string]bool) {
+		if len(xs) == 0 || len(ys) == 0 {
+			return
+		}
+		b.fill(dst, len(xs)*len(ys), func(yield func(string) bool) {
+			rs := sortedKeys(ys)
+			for _, l := range sortedKeys(xs) {
+				for _, r := range rs {
+					if !yield(l + r) {
+						return
+					}
+				}
+			}
+		})
+	}
+	product(res.atoms, x.atoms, y.atoms)
+	product(res.lefts, x.atoms, y.lefts)
+	product(res.rights, x.rights, y.atoms)
+	for l := range x.lefts {
+		res.lefts[l] = true
+	}
+	for r := range y.rights {
+		res.rights[r] = true
+	}
+	words := make(map[string]bool)
+	product(words, x.rights, y.lefts)
+	for w := range words {
+		b.addWord(w)
+	}
+	return res
+}
+
+// ruleSet returns the shapes of the outputs of a rule set for the integers lo..hi, and for the string rules
+// matching the input kind
+func (b *vocabularyBuilder) ruleSet(rs RuleSet, lo, hi int, kind inputKind) shapes {
+	key := fmt.Sprintf("%s %d %d %d", rs.Name, lo, hi, kind)
+	if res, ok := b.memo[key]; ok {
+		return res
+	}
+	res := newShapes()
+	if b.active[key] {
+		return res
+	}
+	b.active[key] = true
+	defer delete(b.active, key)
+
+	ints := []int{}
+	for i, r := range rs.Rules {
+		if r.Base.IsInt() {
+			ints = append(ints, i)
+		}
+	}
+	for j, i := range ints {
+		r := rs.Rules[i]
+		next := maxInt
+		if j+1 < len(ints) {
+			next = rs.Rules[ints[j+1]].Base.Int - 1
+		}
+		l, h := lo, hi
+		if r.Base.Int > l {
+			l = r.Base.Int
+		}
+		if next < h {
+			h = next
+		}
+		if l > h {
+			continue
+		}
+		b.add(res, b.intRule(rs, r, l, h))
+	}
+	for _, r := range rs.Rules {
+		if r.Base.IsInt() {
+			continue
+		}
+		if kind == anyInput || (kind == decimalInput && r.Base.String != "-x") {
+			b.add(res, b.stringRule(rs, r))
+		}
+	}
+	b.memo[key] = res
+	return res
+}
+
+// intRule returns the shapes of the outputs of an integer rule for the integers lo..hi
+func (b *vocabularyBuilder) intRule(rs RuleSet, r BaseRule, lo, hi int) shapes {
+	d := r.Base.Divisor()
+	hasOptional := false
+	for _, sub := range r.Subs {
+		hasOptional = hasOptional || sub.Optional
+	}
+	res := newShapes()
+
+	// the optional subs are omitted if the number is a multiple of the divisor
+	multiple := lo%d == 0 || hi-lo >= d-lo%d
+	nonMultiple := d > 1 && (hi > lo || lo%d != 0)
+	remainder := func(nonZero bool) (int, int) {
+		rlo, rhi := 0, d-1
+		if hi-lo+1 < d && lo%d <= hi%d {
+			rlo, rhi = lo%d, hi%d
+		}
+		if nonZero && rlo == 0 {
+			rlo = 1
+		}
+		return rlo, rhi
+	}
+	if !hasOptional {
+		rlo, rhi := remainder(false)
+		return b.subs(rs, r, false, lo, hi, rlo, rhi)
+	}
+	if multiple {
+		b.add(res, b.subs(rs, r, true, lo, hi, 0, 0))
+	}
+	if nonMultiple {
+		rlo, rhi := remainder(true)
+		b.add(res, b.subs(rs, r, false, lo, hi, rlo, rhi))
+	}
+	return res
+}
+
+// subs returns the shapes of the concatenated subs of an integer rule for the integers lo..hi, with remainders rlo..rhi
+func (b *vocabularyBuilder) subs(rs RuleSet, r BaseRule, omitOptional bool, lo, hi, rlo, rhi int) shapes {
+	d := r.Base.Divisor()
+	res := b.text("")
+	for _, sub := range r.Subs {
+		if sub.Optional && omitOptional {
+			continue
+		}
+		var s shapes
+		switch sub.Operation {
+		case ">>":
+			s = b.sub(rs, r, sub, rlo, rhi, intInput)
+		case "<<":
+			s = b.sub(rs, r, sub, lo/d, hi/d, intInput)
+		default:
+			s = b.sub(rs, r, sub, lo, hi, intInput)
+		}
+		res = b.join(res, s)
+	}
+	return res
+}
+
+// matchable reports whether rules with the base can be matched by BaseRule.Match (Inf and NaN rules are never matched)
+func matchable(base Base) bool {
+	switch base.String {
+	case "", "-x", "x.x", "x,x", "x%":
+		return true
+	}
+	return false
+}
+
+// stringRule returns the shapes of the outputs of a string rule (-x, x.x, x,x)
+func (b *vocabularyBuilder) stringRule(rs RuleSet, r BaseRule) shapes {
+	if !matchable(r.Base) {
+		return newShapes()
+	}
+	res := b.text("")
+	for _, sub := range r.Subs {
+		var s shapes
+		switch {
+		case r.Base.String == "-x" && sub.Operation == "<<":
+			continue
+		case r.Base.String == "-x" && sub.Operation == ">>":
+			s = b.sub(rs, r, sub, 0, maxInt, decimalInput)
+		default:
+			s = b.sub(rs, r, sub, 0, maxInt, intInput)
+		}
+		res = b.join(res, s)
+	}
+	return res
+}
+
+// sub returns the shapes of the output of a sub, for the integers lo..hi
+func (b *vocabularyBuilder) sub(rs RuleSet, r BaseRule, sub Sub, lo, hi int, kind inputKind) shapes {
+	switch {
+	case sub.IsNumericFormatter():
+		b.open[OpenSub{RuleSet: rs.Name, Base: r.Base.Value(), Sub: sub.String()}] = true
+		res := newShapes()
+		res.atoms["{"+sub.NumericFormatter.String()+"}"] = true
+		return res
+	case sub.IsPluralFormatter():
+		res := newShapes()
+		for _, c := range sub.PluralFormatter.cases {
+			b.add(res, b.text(strings.Trim(c.text, "'")))
+		}
+		return res
+	case sub.IsRuleRef():
+		if ref, ok := b.g.FindRuleSet(sub.RuleRef); ok {
+			return b.ruleSet(ref, lo, hi, kind)
+		}
+		return newShapes()
+	case sub.Operation != "":
+		return b.ruleSet(rs, lo, hi, kind)
+	default:
+		return b.text(strings.Trim(sub.Orth, "'"))
+	}
+}
+
+// Vocabulary returns the words the public rule sets of the group can produce (or the rule sets given by the
+// VocabularyRuleSets option), found by static analysis of the rules rather than by spelling out numbers.
+// Literal fragments joined without spaces are combined into candidate compounds (tjugo + ett = tjugoett),
+// following the number ranges that each sub can be applied to. Numeric formatters, such as =#,##0=, produce
+// open-ended output, and are reported in Vocabulary.Open. Words are separated by spaces, as in the spellout
+// output; other characters, such as hyphens and soft hyphens, are kept in the words. Rules for Inf and NaN
+// are not included, since they are never matched.
+func (g RuleSetGroup) Vocabulary(opts ...VocabularyOption) Vocabulary {
+	o := vocabularyOptions{limit: DefaultVocabularyLimit}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if len(o.ruleSets) == 0 {
+		for name, rs := range g.RuleSets {
+			if !rs.Private {
+				o.ruleSets = append(o.ruleSets, name)
+			}
+		}
+		sort.Strings(o.ruleSets)
+	}
+
+	b := &vocabularyBuilder{
+		g:      g,
+		limit:  o.limit,
+		words:  make(map[string]bool),
+		memo:   make(map[string]shapes),
+		active: make(map[string]bool),
+		open:   make(map[OpenSub]bool),
+	}
+	for _, name := range o.ruleSets {
+		rs, ok := g.FindRuleSet(name)
+		if !ok {
+			continue
+		}
+		s := b.ruleSet(rs, 0, maxInt, anyInput)
+		for _, m := range []map[string]bool{s.atoms, s.lefts, s.rights} {
+			for w := range m {
+				b.addWord(w)
+			}
+		}
+	}
+
+	res := Vocabulary{Fragments: []string{}, Words: []string{}, OpenWords: []string{}, Open: []OpenSub{}, Truncated: b.truncated}
+	for _, w := range sortedKeys(b.words) {
+		if strings.Contains(w, "{") {
+			res.OpenWords = append(res.OpenWords, w)
+		} else {
+			res.Words = append(res.Words, w)
+		}
+	}
+	for sub := range b.open {
+		res.Open = append(res.Open, sub)
+	}
+	sort.Slice(res.Open, func(i, j int) bool {
+		a, b := res.Open[i], res.Open[j]
+		if a.RuleSet != b.RuleSet {
+			return a.RuleSet < b.RuleSet
+		}
+		if a.Base != b.Base {
+			return a.Base < b.Base
+		}
+		return a.Sub < b.Sub
+	})
+
+	fragments := make(map[string]bool)
+	for _, name := range g.Graph().ReachableFrom(o.ruleSets...) {
+		rs, ok := g.RuleSets[name]
+		if !ok {
+			continue
+		}
+		for _, r := range rs.Rules {
+			if !matchable(r.Base) {
+				continue
+			}
+			for _, sub := range r.Subs {
+				texts := []string{sub.Orth}
+				if sub.IsPluralFormatter() {
+					texts = nil
+					for _, c := range sub.PluralFormatter.cases {
+						texts = append(texts, c.text)
+					}
+				}
+				for _, t := range texts {
+					for _, f := range strings.Split(strings.Trim(t, "'"), " ") {
+						if f != "" {
+							fragments[f] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	res.Fragments = sortedKeys(fragments)
+	return res
+}
@@ -0,0 +1,63 @@
+package rbnf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVocabulary(t *testing.T) {
+	g := testGroup(t)
+	v := g.Vocabulary()
+
+	// 20..23: tjugo, tjugoett, tjugotvå, tjugotre (tjugonoll is never produced)
+	// 100..399: etthundra, tvåhundra, trehundra, followed by the words of 1..99
+	exp := "ett etthundra minus noll tjugo tjugoett tjugotre tjugotvå tre trehundra två tvåhundra"
+	if res := strings.Join(v.Words, " "); res != exp {
+		t.Errorf(fs, exp, res)
+	}
+	if exp, res := "tjugo{#,##0} {#,##0} {#,##0}:e", strings.Join(v.OpenWords, " "); res != exp {
+		t.Errorf(fs, exp, res)
+	}
+	if exp, res := ":e ett hundra minus noll tjugo tre två", strings.Join(v.Fragments, " "); res != exp {
+		t.Errorf(fs, exp, res)
+	}
+	if exp, res := 4, len(v.Open); res != exp {
+		t.Errorf(fs, exp, res)
+	} else if exp, res := (OpenSub{RuleSet: "digits-ordinal", Base: "0", Sub: "=#,##0="}), v.Open[0]; res != exp {
+		t.Errorf(fs, exp, res)
+	}
+	if v.Truncated {
+		t.Error("expected complete vocabulary")
+	}
+
+	// words produced by spellout are in the vocabulary
+	words := make(map[string]bool)
+	for _, w := range append(v.Words, v.OpenWords...) {
+		words[w] = true
+	}
+	for _, input := range []string{"-3", "0", "2", "21", "23", "100", "101", "123", "222", "300"} {
+		res, err := g.Spellout(input, "spellout-numbering", false)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		for _, w := range strings.Fields(res) {
+			if !words[w] {
+				t.Errorf("%s (%s) not in vocabulary", w, input)
+			}
+		}
+	}
+
+	v = g.Vocabulary(VocabularyRuleSets("digits-ordinal"))
+	if exp, res := 0, len(v.Words); res != exp {
+		t.Errorf(fs, exp, res)
+	}
+	if exp, res := ":e", strings.Join(v.Fragments, " "); res != exp {
+		t.Errorf(fs, exp, res)
+	}
+
+	v = g.Vocabulary(VocabularyLimit(3))
+	if !v.Truncated {
+		t.Error("expected truncated vocabulary")
+	}
+}
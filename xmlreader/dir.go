@@ -0,0 +1,215 @@
+package xmlreader
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/stts-se/rbnf"
+)
+
+// FileError is an error loading a rule file of a Dir
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%s : %v", e.Path, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+type dirEntry struct {
+	lang rbnf.Language
+	path string
+	once sync.Once
+	pkg  *rbnf.RulePackage
+	err  error
+
+	// the rules of the file before inheritance, shared by the packages of its child locales
+	layerOnce sync.Once
+	layer     rbnf.RulePackage
+	layerErr  error
+}
+
+// Dir holds the rule packages of the rule files in a directory, such as the common/rbnf directory of CLDR,
+// indexed by language. The language of a file is its base name (de_CH.xml: de_CH), which must match the
+// locale of its identity element. Packages are loaded when the directory is read, or, if lazy, the first time
+// they are requested. A file that fails to load doesn't affect the other files. A Dir is safe for concurrent use.
+type Dir struct {
+	fsys    fs.FS
+	inherit bool
+	langs   []rbnf.Language
+	entries map[rbnf.Language]*dirEntry
+
+	mu   sync.Mutex
+	errs []error
+}
+
+type dirOptions struct {
+	lazy    bool
+	inherit bool
+}
+
+// DirOption is an option for LoadDir
+type DirOption func(*dirOptions)
+
+// DirLazy makes LoadDir load packages the first time they are requested, instead of when the directory is read
+func DirLazy() DirOption {
+	return func(o *dirOptions) { o.lazy = true }
+}
+
+// DirInherit makes LoadDir merge each file with the files of its parent locales in the directory (see RulesFromFSInherited).
+// Each file is read once, also if it is the parent of several locales.
+func DirInherit() DirOption {
+	return func(o *dirOptions) { o.inherit = true }
+}
+
+// LoadDir reads the rule files (*.xml) in a directory of a file system, such as an embed.FS, a zip archive or
+// os.DirFS("cldr/common/rbnf"). Subdirectories are not read. An error is returned only if the directory can't be
+// read; errors for individual files are returned by Package and Errors.
+func LoadDir(fsys fs.FS, dir string, opts ...DirOption) (*Dir, error) {
+	o := dirOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	files, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("LoadDir: %v", err)
+	}
+
+	res := &Dir{fsys: fsys, inherit: o.inherit, entries: make(map[rbnf.Language]*dirEntry)}
+	for _, f := range files {
+		if f.IsDir() || path.Ext(f.Name()) != ".xml" {
+			continue
+		}
+		lang := rbnf.Language(strings.TrimSuffix(f.Name(), ".xml"))
+		res.langs = append(res.langs, lang)
+		res.entries[lang] = &dirEntry{lang: lang, path: path.Join(dir, f.Name())}
+	}
+	sort.Slice(res.langs, func(i, j int) bool { return res.langs[i] < res.langs[j] })
+
+	if !o.lazy {
+		for _, lang := range res.langs {
+			res.get(res.entries[lang])
+		}
+	}
+	return res, nil
+}
+
+func (d *Dir) get(e *dirEntry) (*rbnf.RulePackage, error) {
+	e.once.Do(func() {
+		pkg, err := d.load(e)
+		if err != nil {
+			e.err = &FileError{Path: e.path, Err: err}
+			d.mu.Lock()
+			d.errs = append(d.errs, e.err)
+			d.mu.Unlock()
+			return
+		}
+		e.pkg = &pkg
+	})
+	return e.pkg, e.err
+}
+
+func (d *Dir) load(e *dirEntry) (rbnf.RulePackage, error) {
+	if !d.inherit {
+		pkg, err := RulesFromFS(d.fsys, e.path)
+		if err != nil {
+			return rbnf.RulePackage{}, err
+		}
+		if pkg.Language != e.lang {
+			return rbnf.RulePackage{}, fmt.Errorf("file name doesn't match locale %s", pkg.Language)
+		}
+		return pkg, nil
+	}
+
+	child, err := d.layer(e)
+	if err != nil {
+		return rbnf.RulePackage{}, err
+	}
+	var parents []rbnf.RulePackage
+	for _, loc := range parentLocales(string(e.lang)) {
+		p, ok := d.entries[rbnf.Language(loc)]
+		if !ok {
+			if Verb {
+				log.Printf("[xmlreader] no rule file for parent locale %s", loc)
+			}
+			continue
+		}
+		pkg, err := d.layer(p)
+		if err != nil {
+			return rbnf.RulePackage{}, fmt.Errorf("%s : %v", p.path, err)
+		}
+		parents = append(parents, pkg)
+	}
+	return inheritPackage(child, parents)
+}
+
+// layer reads the rules of a file before inheritance, once
+func (d *Dir) layer(e *dirEntry) (rbnf.RulePackage, error) {
+	e.layerOnce.Do(func() {
+		e.layer, e.layerErr = layerFromFS(d.fsys, e.path)
+		if e.layerErr == nil && e.layer.Language != e.lang {
+			e.layerErr = fmt.Errorf("file name doesn't match locale %s", e.layer.Language)
+		}
+	})
+	return e.layer, e.layerErr
+}
+
+// Languages returns the languages of the rule files in the directory, sorted
+func (d *Dir) Languages() []rbnf.Language {
+	return append([]rbnf.Language{}, d.langs...)
+}
+
+// Package returns the rule package of a language, loading it if needed. A *FileError is returned if the file failed to load.
+func (d *Dir) Package(lang rbnf.Language) (*rbnf.RulePackage, error) {
+	e, ok := d.entries[lang]
+	if !ok {
+		return nil, fmt.Errorf("no rule file for language %s", lang)
+	}
+	return d.get(e)
+}
+
+// Packages returns the rule packages of all languages that load without errors, loading them if needed
+func (d *Dir) Packages() map[rbnf.Language]*rbnf.RulePackage {
+	res := make(map[rbnf.Language]*rbnf.RulePackage)
+	for _, lang := range d.langs {
+		if pkg, err := d.get(d.entries[lang]); err == nil {
+			res[lang] = pkg
+		}
+	}
+	return res
+}
+
+// Errors returns the errors (of type *FileError) of the files loaded so far, in load order
+func (d *Dir) Errors() []error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]error{}, d.errs...)
+}
+
+// Register registers a loader for each language of the directory in a rbnf.Registry, except root.
+// The packages are shared by the Dir and the Registry.
+func (d *Dir) Register(reg *rbnf.Registry) {
+	for _, lang := range d.langs {
+		if lang == "root" {
+			continue
+		}
+		e := d.entries[lang]
+		reg.Register(lang.Tag(), func() (rbnf.RulePackage, error) {
+			pkg, err := d.get(e)
+			if err != nil {
+				return rbnf.RulePackage{}, err
+			}
+			return *pkg, nil
+		})
+	}
+}
@@ -13,9 +13,11 @@ type Ldml struct {
 }
 
 type Identity struct {
-	XMLName  xml.Name  `xml:"identity,omitempty" json:"identity,omitempty"`
-	Language *Language `xml:"language,omitempty" json:"language,omitempty"`
-	Version  *Version  `xml:"version,omitempty" json:"version,omitempty"`
+	XMLName   xml.Name   `xml:"identity,omitempty" json:"identity,omitempty"`
+	Language  *Language  `xml:"language,omitempty" json:"language,omitempty"`
+	Script    *Script    `xml:"script,omitempty" json:"script,omitempty"`
+	Territory *Territory `xml:"territory,omitempty" json:"territory,omitempty"`
+	Version   *Version   `xml:"version,omitempty" json:"version,omitempty"`
 }
 
 type Version struct {
@@ -28,6 +30,16 @@ type Language struct {
 	Attrtype string   `xml:"type,attr"  json:",omitempty"`
 }
 
+type Script struct {
+	XMLName  xml.Name `xml:"script,omitempty" json:"script,omitempty"`
+	Attrtype string   `xml:"type,attr"  json:",omitempty"`
+}
+
+type Territory struct {
+	XMLName  xml.Name `xml:"territory,omitempty" json:"territory,omitempty"`
+	Attrtype string   `xml:"type,attr"  json:",omitempty"`
+}
+
 type Rbnf struct {
 	XMLName         xml.Name           `xml:"rbnf,omitempty" json:"rbnf,omitempty"`
 	RulesetGrouping []*RulesetGrouping `xml:"rulesetGrouping,omitempty" json:"rulesetGrouping,omitempty"`
@@ -37,13 +49,18 @@ type RulesetGrouping struct {
 	XMLName  xml.Name   `xml:"rulesetGrouping,omitempty" json:"rulesetGrouping,omitempty"`
 	Attrtype string     `xml:"type,attr"  json:",omitempty"`
 	Ruleset  []*Ruleset `xml:"ruleset,omitempty" json:"ruleset,omitempty"`
+	// Attroverlay is the overlay mode of the rule sets in RbnfRules, in overlay files
+	Attroverlay string `xml:"overlay,attr"  json:",omitempty"`
+	// RbnfRules holds the rules in ICU rule syntax, instead of Ruleset elements (CLDR 44 and later)
+	RbnfRules string `xml:"rbnfRules,omitempty" json:"rbnfRules,omitempty"`
 }
 
 type Ruleset struct {
-	XMLName    xml.Name    `xml:"ruleset,omitempty" json:"ruleset,omitempty"`
-	Attraccess string      `xml:"access,attr"  json:",omitempty"`
-	Attrtype   string      `xml:"type,attr"  json:",omitempty"`
-	Rbnfrule   []*Rbnfrule `xml:"rbnfrule,omitempty" json:"rbnfrule,omitempty"`
+	XMLName     xml.Name    `xml:"ruleset,omitempty" json:"ruleset,omitempty"`
+	Attraccess  string      `xml:"access,attr"  json:",omitempty"`
+	Attrtype    string      `xml:"type,attr"  json:",omitempty"`
+	Attroverlay string      `xml:"overlay,attr"  json:",omitempty"`
+	Rbnfrule    []*Rbnfrule `xml:"rbnfrule,omitempty" json:"rbnfrule,omitempty"`
 }
 
 type Rbnfrule struct {
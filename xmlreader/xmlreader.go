@@ -1,5 +1,9 @@
 /** Package xmlreader contains a parser for the CLDR RBNF format https://github.com/unicode-org/cldr/tree/master/common/rbnf
 
+Both the older layout (one rbnfrule element per rule) and the layout of CLDR 44 and later (rules in ICU rule syntax, in an rbnfRules element per rule set group) are supported.
+
+Rule files are read from a file, a URL, an io.Reader or an fs.FS. Directories of rule files, such as common/rbnf of CLDR, are read using LoadDir.
+
 License for CLDR: https://github.com/unicode-org/cldr/blob/master/ICU-LICENSE
 
 */
@@ -7,24 +11,33 @@ package xmlreader
 
 import (
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
+	"path"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/stts-se/rbnf"
+	"github.com/stts-se/rbnf/icureader"
 	"github.com/stts-se/rbnf/lexer"
+	"golang.org/x/text/language"
 )
 
 var Verb = false
 
-func readXMLFile(fn string) (Ldml, error) {
+// readXML reads and unmarshals the XML of a rule file
+func readXML(r io.Reader) (Ldml, error) {
 	res := Ldml{}
 
-	bytes, err := ioutil.ReadFile(fn)
+	bytes, err := ioutil.ReadAll(r)
 	if err != nil {
 		return res, fmt.Errorf("failed to read XML file : %v", err)
 	}
@@ -37,29 +50,34 @@ func readXMLFile(fn string) (Ldml, error) {
 	return res, nil
 }
 
-func readXMLURL(url string) (Ldml, error) {
-	res := Ldml{}
-
-	resp, err := http.Get(url)
+func readXMLFile(fn string) (Ldml, error) {
+	f, err := os.Open(fn)
 	if err != nil {
-		return res, fmt.Errorf("failed to read URL : %v", err)
+		return Ldml{}, fmt.Errorf("failed to read XML file : %v", err)
 	}
-	if resp.StatusCode != http.StatusOK {
-		return res, fmt.Errorf("failed to read URL : %v", resp.Status)
-	}
-	defer resp.Body.Close()
+	defer f.Close()
+	return readXML(f)
+}
 
-	bytes, err := ioutil.ReadAll(resp.Body)
+func readXMLFS(fsys fs.FS, name string) (Ldml, error) {
+	f, err := fsys.Open(name)
 	if err != nil {
-		return res, fmt.Errorf("failed to read XML file : %v", err)
+		return Ldml{}, fmt.Errorf("failed to read XML file : %v", err)
 	}
+	defer f.Close()
+	return readXML(f)
+}
 
-	err = xml.Unmarshal(bytes, &res)
+func readXMLURL(url string) (Ldml, error) {
+	resp, err := http.Get(url)
 	if err != nil {
-		return res, fmt.Errorf("failed to process XML file : %v", err)
+		return Ldml{}, fmt.Errorf("failed to read URL : %v", err)
 	}
-
-	return res, nil
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Ldml{}, fmt.Errorf("failed to read URL : %v", resp.Status)
+	}
+	return readXML(resp.Body)
 }
 
 func replaceChars(s string) string {
@@ -88,7 +106,10 @@ func convertRuleSet(rs *Ruleset, lang string) (rbnf.RuleSet, error) {
 	for _, r := range rs.Rbnfrule {
 		//fmt.Printf("RULE %#v\n", r)
 		rule := rbnf.BaseRule{}
-		baseNum, err := strconv.Atoi(strings.Replace(r.Attrvalue, ",", "", -1))
+		value := strings.Replace(r.Attrvalue, ",", "", -1)
+		// each > after the base value decreases the exponent of the divisor
+		decrement := len(value) - len(strings.TrimRight(value, ">"))
+		baseNum, err := strconv.Atoi(strings.TrimRight(value, ">"))
 		if err == nil { // numeric rule
 			// TODO test
 			radix := 10 // Default radix
@@ -100,6 +121,7 @@ func convertRuleSet(rs *Ruleset, lang string) (rbnf.RuleSet, error) {
 
 			}
 			rule.Base = rbnf.NewBaseInt(baseNum, radix)
+			rule.Base.Decrement = decrement
 		} else { // non-numeric rule
 			rule.Base = rbnf.NewBaseString(r.Attrvalue)
 		}
@@ -150,6 +172,15 @@ func convertGroup(g *RulesetGrouping, lang string) (string, []rbnf.RuleSet, erro
 		return "", res, fmt.Errorf("rule set grouping lacks type attribute value")
 	}
 
+	// CLDR 44 and later: rules in ICU rule syntax
+	if strings.TrimSpace(g.RbnfRules) != "" {
+		parsed, err := icureader.ParseRuleSets(g.RbnfRules, rbnf.Language(lang))
+		if err != nil {
+			return name, res, fmt.Errorf("failed to parse rules of rule set group %s : %v", name, err)
+		}
+		res = append(res, parsed...)
+	}
+
 	for _, rs := range g.Ruleset {
 		rbnfRuleSet, err := convertRuleSet(rs, lang)
 		if err != nil {
@@ -168,7 +199,9 @@ func convertGroup(g *RulesetGrouping, lang string) (string, []rbnf.RuleSet, erro
 	return name, res, fmt.Errorf("no rule sets for rule set group %s", name)
 }
 
-func rulesFromLdml(ldml Ldml, lang string) ([]rbnf.RuleSetGroup, error) {
+// rulesFromLdml converts the rule set groups of a file. If validate is false, rule references
+// are not checked (used for files layered on parent locales, see RulesFromXMLFileInherited).
+func rulesFromLdml(ldml Ldml, lang string, validate bool) ([]rbnf.RuleSetGroup, error) {
 	//var res rbnf.RuleSetGroup
 	res := []rbnf.RuleSetGroup{}
 	// name of whole file
@@ -186,6 +219,14 @@ func rulesFromLdml(ldml Ldml, lang string) ([]rbnf.RuleSetGroup, error) {
 			//fmt.Fprintf(os.Stderr, "skipping rule group '%s' : %v", name, err)
 			//continue
 		}
+		if !validate {
+			ruleSets := make(map[string]rbnf.RuleSet)
+			for _, rs := range ruleSet {
+				ruleSets[rs.Name] = rs
+			}
+			rbnfGroups = append(rbnfGroups, rbnf.RuleSetGroup{Name: name, Language: rbnf.Language(lang), RuleSets: ruleSets})
+			continue
+		}
 		group, err := rbnf.NewRuleSetGroup(name, rbnf.Language(lang), ruleSet)
 		if err != nil {
 
@@ -205,36 +246,240 @@ func rulesFromLdml(ldml Ldml, lang string) ([]rbnf.RuleSetGroup, error) {
 	return res, nil
 }
 
-func RulesFromXMLFile(fn string) (rbnf.RulePackage, error) {
-	var lang string
+// locale returns the locale of a rule file, from the language, script and territory of its identity
+// (de_CH, sr_Latn, en_001), as in CLDR rule file names
+func (ldml Ldml) locale() string {
+	res := []string{ldml.Identity.Language.Attrtype}
+	if ldml.Identity.Script != nil && ldml.Identity.Script.Attrtype != "" {
+		res = append(res, ldml.Identity.Script.Attrtype)
+	}
+	if ldml.Identity.Territory != nil && ldml.Identity.Territory.Attrtype != "" {
+		res = append(res, ldml.Identity.Territory.Attrtype)
+	}
+	return strings.Join(res, "_")
+}
+
+// packageFromLdml converts the rule set groups of a complete rule file to a rule package
+func packageFromLdml(ldml Ldml) (rbnf.RulePackage, error) {
+	if ldml.Identity == nil || ldml.Identity.Language == nil || ldml.Rbnf == nil {
+		return rbnf.RulePackage{}, fmt.Errorf("missing identity language or rbnf element")
+	}
+	lang := ldml.locale()
+
+	groups, err := rulesFromLdml(ldml, lang, true)
+	if err != nil {
+		return rbnf.RulePackage{}, err
+	}
+
+	return rbnf.NewRulePackage(rbnf.Language(lang), groups, false)
+}
 
+// RulesFromXMLFile reads a rule package from a rule file. The package language is the locale of the file identity,
+// including script and territory (de_CH.xml: de_CH, where earlier versions used the bare language, de).
+func RulesFromXMLFile(fn string) (rbnf.RulePackage, error) {
 	ldml, err := readXMLFile(fn)
 	if err != nil {
 		return rbnf.RulePackage{}, fmt.Errorf("RulesFromXMLFile: %v", err)
 	}
-	lang = ldml.Identity.Language.Attrtype
-
-	groups, err := rulesFromLdml(ldml, lang)
+	res, err := packageFromLdml(ldml)
 	if err != nil {
 		return rbnf.RulePackage{}, fmt.Errorf("RulesFromXMLFile: %v", err)
 	}
-
-	return rbnf.NewRulePackage(rbnf.Language(lang), groups, false)
+	return res, nil
 }
 
 func RulesFromXMLURL(url string) (rbnf.RulePackage, error) {
-	var lang string
-
 	ldml, err := readXMLURL(url)
 	if err != nil {
 		return rbnf.RulePackage{}, fmt.Errorf("RulesFromXMLURL: %v", err)
 	}
-	lang = ldml.Identity.Language.Attrtype
-
-	groups, err := rulesFromLdml(ldml, lang)
+	res, err := packageFromLdml(ldml)
 	if err != nil {
 		return rbnf.RulePackage{}, fmt.Errorf("RulesFromXMLURL: %v", err)
 	}
+	return res, nil
+}
 
-	return rbnf.NewRulePackage(rbnf.Language(lang), groups, false)
+// RulesFromXMLReader reads a rule package from the XML of a rule file
+func RulesFromXMLReader(r io.Reader) (rbnf.RulePackage, error) {
+	ldml, err := readXML(r)
+	if err != nil {
+		return rbnf.RulePackage{}, fmt.Errorf("RulesFromXMLReader: %v", err)
+	}
+	res, err := packageFromLdml(ldml)
+	if err != nil {
+		return rbnf.RulePackage{}, fmt.Errorf("RulesFromXMLReader: %v", err)
+	}
+	return res, nil
+}
+
+// RulesFromFS reads a rule package from a rule file in a file system, such as an embed.FS or a zip archive
+// (the name is slash-separated, see fs.ValidPath)
+func RulesFromFS(fsys fs.FS, name string) (rbnf.RulePackage, error) {
+	ldml, err := readXMLFS(fsys, name)
+	if err != nil {
+		return rbnf.RulePackage{}, fmt.Errorf("RulesFromFS: %s : %v", name, err)
+	}
+	res, err := packageFromLdml(ldml)
+	if err != nil {
+		return rbnf.RulePackage{}, fmt.Errorf("RulesFromFS: %s : %v", name, err)
+	}
+	return res, nil
+}
+
+// Loader returns a loader reading the rule package from an xml file or url (starting with http), for lazy loading in a rbnf.Registry
+func Loader(f string) rbnf.Loader {
+	return func() (rbnf.RulePackage, error) {
+		if strings.HasPrefix(f, "http") {
+			return RulesFromXMLURL(f)
+		}
+		return RulesFromXMLFile(f)
+	}
+}
+
+// parentLocales returns the CLDR parent locales of a locale (de_CH => de, root; en_IN => en_001, en, root), as used in rule file names
+func parentLocales(locale string) []string {
+	res := []string{}
+	tag := language.Make(strings.Replace(locale, "_", "-", -1))
+	for t := tag.Parent(); !t.IsRoot(); t = t.Parent() {
+		res = append(res, strings.Replace(t.String(), "-", "_", -1))
+	}
+	if locale != "root" {
+		res = append(res, "root")
+	}
+	return res
+}
+
+// RulesFromXMLFileInherited reads a rule file, and merges it with the rule files of its parent
+// locales up to root (see rbnf.InheritRules). The locale is taken from the file name (de_CH.xml),
+// and parent files are read from the same directory (de.xml, root.xml). Missing parent files are skipped.
+func RulesFromXMLFileInherited(fn string) (rbnf.RulePackage, error) {
+	dir, base := filepath.Split(fn)
+	if dir == "" {
+		dir = "."
+	}
+	res, err := rulesFromFSInherited(os.DirFS(dir), base)
+	if err != nil {
+		return rbnf.RulePackage{}, fmt.Errorf("RulesFromXMLFileInherited: %v", err)
+	}
+	return res, nil
+}
+
+// RulesFromFSInherited reads a rule file in a file system, and merges it with the rule files of its
+// parent locales in the same directory (see RulesFromXMLFileInherited)
+func RulesFromFSInherited(fsys fs.FS, name string) (rbnf.RulePackage, error) {
+	res, err := rulesFromFSInherited(fsys, name)
+	if err != nil {
+		return rbnf.RulePackage{}, fmt.Errorf("RulesFromFSInherited: %v", err)
+	}
+	return res, nil
+}
+
+func rulesFromFSInherited(fsys fs.FS, fn string) (rbnf.RulePackage, error) {
+	dir, base := path.Split(fn)
+	locale := strings.TrimSuffix(base, path.Ext(base))
+
+	var child rbnf.RulePackage
+	var parents []rbnf.RulePackage
+	for i, loc := range append([]string{locale}, parentLocales(locale)...) {
+		f := fn
+		if i > 0 {
+			f = path.Join(dir, loc+".xml")
+			if _, err := fs.Stat(fsys, f); errors.Is(err, fs.ErrNotExist) {
+				if Verb {
+					log.Printf("[xmlreader] no rule file for parent locale %s", loc)
+				}
+				continue
+			}
+		}
+		pkg, err := layerFromFS(fsys, f)
+		if err != nil {
+			return rbnf.RulePackage{}, fmt.Errorf("%s : %v", f, err)
+		}
+		if i == 0 {
+			child = pkg
+		} else {
+			parents = append(parents, pkg)
+		}
+	}
+	return inheritPackage(child, parents)
+}
+
+// layerFromFS reads a rule file without checking rule references, as a layer for rbnf.InheritRules
+func layerFromFS(fsys fs.FS, fn string) (rbnf.RulePackage, error) {
+	ldml, err := readXMLFS(fsys, fn)
+	if err != nil {
+		return rbnf.RulePackage{}, err
+	}
+	if ldml.Identity == nil || ldml.Identity.Language == nil || ldml.Rbnf == nil {
+		return rbnf.RulePackage{}, fmt.Errorf("missing identity language or rbnf element")
+	}
+	lang := ldml.locale()
+	groups, err := rulesFromLdml(ldml, lang, false)
+	if err != nil {
+		return rbnf.RulePackage{}, err
+	}
+	return rbnf.RulePackage{Language: rbnf.Language(lang), RuleSetGroups: groups}, nil
+}
+
+// inheritPackage merges a layer with the layers of its parent locales, nearest first, into a validated rule package
+func inheritPackage(child rbnf.RulePackage, parents []rbnf.RulePackage) (rbnf.RulePackage, error) {
+	res, err := rbnf.InheritRules(child, parents...)
+	if err != nil {
+		return rbnf.RulePackage{}, err
+	}
+	return rbnf.NewRulePackage(res.Language, res.RuleSetGroups, false)
+}
+
+// OverlayFromXMLFile reads an overlay file in the LDML rbnf format (see rbnf.Overlay). Each rule set in the
+// file is applied to the rule set group it is listed in. The overlay attribute of a rule set sets the overlay
+// mode: replace (default) to add or replace the whole rule set, or merge to replace or insert individual rules
+// by base value:
+//
+//	<ruleset type="spellout-numbering" overlay="merge">
+//	    <rbnfrule value="100">hundra[­→→];</rbnfrule>
+//	</ruleset>
+//
+// In the CLDR 44+ layout, the overlay attribute of the rule set grouping sets the mode of the rule sets in its rbnfRules element.
+func OverlayFromXMLFile(fn string) (rbnf.Overlay, error) {
+	ldml, err := readXMLFile(fn)
+	if err != nil {
+		return rbnf.Overlay{}, fmt.Errorf("OverlayFromXMLFile: %v", err)
+	}
+	if ldml.Identity == nil || ldml.Identity.Language == nil || ldml.Rbnf == nil {
+		return rbnf.Overlay{}, fmt.Errorf("OverlayFromXMLFile: %s : missing identity language or rbnf element", fn)
+	}
+	lang := ldml.locale()
+
+	res := rbnf.Overlay{Language: rbnf.Language(lang)}
+	for _, g := range ldml.Rbnf.RulesetGrouping {
+		if strings.TrimSpace(g.Attrtype) == "" {
+			return rbnf.Overlay{}, fmt.Errorf("OverlayFromXMLFile: rule set grouping lacks type attribute value")
+		}
+		if strings.TrimSpace(g.RbnfRules) != "" {
+			mode, err := rbnf.ParseOverlayMode(g.Attroverlay)
+			if err != nil {
+				return rbnf.Overlay{}, fmt.Errorf("OverlayFromXMLFile: %v", err)
+			}
+			ruleSets, err := icureader.ParseRuleSets(g.RbnfRules, rbnf.Language(lang))
+			if err != nil {
+				return rbnf.Overlay{}, fmt.Errorf("OverlayFromXMLFile: failed to parse rules of rule set group %s : %v", g.Attrtype, err)
+			}
+			for _, ruleSet := range ruleSets {
+				res.RuleSets = append(res.RuleSets, rbnf.OverlayRuleSet{Group: g.Attrtype, Mode: mode, RuleSet: ruleSet})
+			}
+		}
+		for _, rs := range g.Ruleset {
+			mode, err := rbnf.ParseOverlayMode(rs.Attroverlay)
+			if err != nil {
+				return rbnf.Overlay{}, fmt.Errorf("OverlayFromXMLFile: %v", err)
+			}
+			ruleSet, err := convertRuleSet(rs, lang)
+			if err != nil {
+				return rbnf.Overlay{}, fmt.Errorf("OverlayFromXMLFile: failed to convert rule set : %v", err)
+			}
+			res.RuleSets = append(res.RuleSets, rbnf.OverlayRuleSet{Group: g.Attrtype, Mode: mode, RuleSet: ruleSet})
+		}
+	}
+	return res, nil
 }
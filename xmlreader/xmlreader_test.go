@@ -1,10 +1,22 @@
 package xmlreader
 
 import (
+	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"io/fs"
 	"io/ioutil"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+
+	"github.com/stts-se/rbnf"
+	"github.com/stts-se/rbnf/icureader"
+	"golang.org/x/text/language"
 )
 
 // just to have not to de-import fmt
@@ -361,3 +373,788 @@ func TestRulesFromXMLFileTA(t *testing.T) {
 	}
 
 }
+
+func TestSemanticSpelloutSV(t *testing.T) {
+
+	pack, err := RulesFromXMLFile("test_data/sv.xml")
+	if err != nil {
+		t.Errorf("Pain! %v", err)
+		return
+	}
+
+	tests := []struct {
+		kind   rbnf.Kind
+		input  string
+		expect string
+	}{
+		{rbnf.Cardinal, "21", "tjugo­ett"},
+		{rbnf.Ordinal, "21", "tjugo­första"},
+		{rbnf.Year, "1984", "nitton­hundra­åttio­fyra"},
+		{rbnf.DigitsOrdinal, "21", "21:a"},
+	}
+	for _, test := range tests {
+		res, err := pack.SpelloutKind(test.input, test.kind, false)
+		if err != nil {
+			t.Errorf("Sob! %s %s : %v", test.kind, test.input, err)
+		} else if res != test.expect {
+			t.Errorf("wanted %s, got %s", test.expect, res)
+		}
+	}
+
+	res, err := pack.SpelloutCardinal("1", false)
+	if err != nil {
+		t.Errorf("Sob! %v", err)
+	} else if w := "ett"; res != w {
+		t.Errorf("wanted %s, got %s", w, res)
+	}
+
+	// caller override
+	pack.RuleSetNames = map[rbnf.Kind]string{rbnf.Cardinal: "spellout-cardinal-reale"}
+	res, err = pack.SpelloutCardinal("1", false)
+	if err != nil {
+		t.Errorf("Sob! %v", err)
+	} else if w := "en"; res != w {
+		t.Errorf("wanted %s, got %s", w, res)
+	}
+
+	pack.RuleSetNames = map[rbnf.Kind]string{rbnf.Cardinal: "spellout-cardinal-common"}
+	_, err = pack.SpelloutCardinal("1", false)
+	if err == nil {
+		t.Errorf("expected error here")
+	}
+}
+
+func TestSemanticSpelloutEN(t *testing.T) {
+
+	pack, err := RulesFromXMLFile("test_data/en.xml")
+	if err != nil {
+		t.Errorf("Pain! %v", err)
+		return
+	}
+
+	res, err := pack.SpelloutOrdinal("1066", false)
+	if err != nil {
+		t.Errorf("Sob! %v", err)
+	} else if w := "one thousand sixty-sixth"; res != w {
+		t.Errorf("wanted %s, got %s", w, res)
+	}
+
+	res, err = pack.SpelloutYear("1066", false)
+	if err != nil {
+		t.Errorf("Sob! %v", err)
+	} else if w := "ten sixty-six"; res != w {
+		t.Errorf("wanted %s, got %s", w, res)
+	}
+
+	res, err = pack.DigitsOrdinal("1066", false)
+	if err != nil {
+		t.Errorf("Sob! %v", err)
+	} else if w := "1,066th"; res != w {
+		t.Errorf("wanted %s, got %s", w, res)
+	}
+}
+
+func TestSpelloutFeaturesSV(t *testing.T) {
+
+	pack, err := RulesFromXMLFile("test_data/sv.xml")
+	if err != nil {
+		t.Errorf("Pain! %v", err)
+		return
+	}
+
+	tests := []struct {
+		req    rbnf.Features
+		expect string
+	}{
+		{rbnf.Features{Kind: rbnf.Cardinal, Gender: "common"}, "en"},
+		{rbnf.Features{Kind: rbnf.Cardinal, Gender: "neuter"}, "ett"},
+		{rbnf.Features{Kind: rbnf.Ordinal, Gender: "masculine", Case: "dative"}, "förste"},
+		{rbnf.Features{Kind: rbnf.DigitsOrdinal, Gender: "masculine"}, "1:e"},
+	}
+	for _, test := range tests {
+		res, err := pack.SpelloutFeatures("1", test.req, false)
+		if err != nil {
+			t.Errorf("Sob! %v : %v", test.req, err)
+		} else if res != test.expect {
+			t.Errorf("wanted %s, got %s", test.expect, res)
+		}
+	}
+}
+
+func TestMessageFormatEN(t *testing.T) {
+
+	pack, err := RulesFromXMLFile("test_data/en.xml")
+	if err != nil {
+		t.Errorf("Pain! %v", err)
+		return
+	}
+
+	tests := []struct {
+		pattern string
+		n       int
+		expect  string
+	}{
+		{"{n, spellout} {n, plural, one {cat} other {cats}}", 21, "twenty-one cats"},
+		{"the {n, spellout, %spellout-ordinal} time", 1066, "the one thousand sixty-sixth time"},
+		{"the {n, ordinal} time", 3, "the 3rd time"},
+		{"{n, selectordinal, one {#st} two {#nd} few {#rd} other {#th}} place", 22, "22nd place"},
+	}
+	for _, test := range tests {
+		res, err := pack.FormatMessage(test.pattern, map[string]interface{}{"n": test.n})
+		if err != nil {
+			t.Errorf("Sob! %s : %v", test.pattern, err)
+		} else if res != test.expect {
+			t.Errorf("wanted %s, got %s", test.expect, res)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	reg := rbnf.NewRegistry()
+	for _, lang := range []string{"sv", "en", "de", "fr", "es", "ta"} {
+		reg.Register(language.Make(lang), Loader("test_data/"+lang+".xml"))
+	}
+
+	tests := []struct {
+		tag    string
+		kind   rbnf.Kind
+		input  string
+		expect string
+	}{
+		{"sv-FI", rbnf.Cardinal, "21", "tjugo­ett"},
+		{"de-AT", rbnf.Cardinal, "21", "ein­und­zwanzig"},
+		{"en-GB", rbnf.Ordinal, "3", "third"},
+		{"fr-CA", rbnf.Cardinal, "80", "quatre-vingts"},
+	}
+	for _, test := range tests {
+		res, err := reg.SpelloutKind(language.MustParse(test.tag), test.input, test.kind, false)
+		if err != nil {
+			t.Errorf("Sob! %s : %v", test.tag, err)
+		} else if res != test.expect {
+			t.Errorf("wanted %s, got %s", test.expect, res)
+		}
+	}
+
+	if _, err := reg.Package(language.Japanese); err == nil {
+		t.Errorf("expected error for %s", language.Japanese)
+	}
+}
+
+func TestRulesFromXMLFileInheritedDECH(t *testing.T) {
+
+	if _, err := RulesFromXMLFile("test_data/de_CH.xml"); err == nil {
+		t.Errorf("expected error when reading de_CH without its parents")
+	}
+
+	pack, err := RulesFromXMLFileInherited("test_data/de_CH.xml")
+	if err != nil {
+		t.Errorf("Pain! %v", err)
+		return
+	}
+	// the locale includes the territory of the identity
+	if w, res := rbnf.Language("de_CH"), pack.Language; res != w {
+		t.Errorf("wanted %s, got %s", w, res)
+	}
+	for _, g := range pack.RuleSetGroups {
+		if w, res := rbnf.Language("de_CH"), g.Language; res != w {
+			t.Errorf("wanted %s, got %s for group %s", w, res, g.Name)
+		}
+	}
+
+	tests := []struct {
+		group   string
+		ruleSet string
+		input   string
+		expect  string
+	}{
+		// overridden in de_CH
+		{"SpelloutRules", "spellout-numbering", "31", "ein­und­dreissig"},
+		// inherited from de, referring to the de_CH override
+		{"SpelloutRules", "spellout-numbering-year", "1930", "neunzehn­hundert­dreissig"},
+		{"SpelloutRules", "spellout-ordinal", "3", "dritte"},
+		// inherited from root
+		{"NumberingSystemRules", "roman-upper", "1984", "MCMLXXXIV"},
+		{"NumberingSystemRules", "roman-lower", "49", "xlix"},
+		{"OrdinalRules", "digits-ordinal", "3", "3."},
+	}
+	for _, test := range tests {
+		res, err := pack.Spellout(test.input, test.group, test.ruleSet, false)
+		if err != nil {
+			t.Errorf("Sob! %s %s : %v", test.ruleSet, test.input, err)
+		} else if res != test.expect {
+			t.Errorf("wanted %s, got %s", test.expect, res)
+		}
+	}
+
+	// root rule sets are available from every locale
+	pack, err = RulesFromXMLFileInherited("test_data/sv.xml")
+	if err != nil {
+		t.Errorf("Pain! %v", err)
+		return
+	}
+	res, err := pack.Spellout("2019", "NumberingSystemRules", "roman-upper", false)
+	if err != nil {
+		t.Errorf("Sob! %v", err)
+	} else if w := "MMXIX"; res != w {
+		t.Errorf("wanted %s, got %s", w, res)
+	}
+}
+
+func TestLocale(t *testing.T) {
+	tests := []struct {
+		identity string
+		expect   string
+	}{
+		{`<language type="sv"/>`, "sv"},
+		{`<language type="de"/><territory type="CH"/>`, "de_CH"},
+		{`<language type="sr"/><script type="Latn"/>`, "sr_Latn"},
+		{`<language type="en"/><territory type="001"/>`, "en_001"},
+	}
+	for _, test := range tests {
+		ldml := Ldml{}
+		if err := xml.Unmarshal([]byte("<ldml><identity>"+test.identity+"</identity></ldml>"), &ldml); err != nil {
+			t.Errorf("Sob! %v", err)
+		} else if res := ldml.locale(); res != test.expect {
+			t.Errorf("wanted %s, got %s", test.expect, res)
+		}
+	}
+}
+
+func TestRulesFromXMLFileLanguage(t *testing.T) {
+	// the package language of the regional file includes the territory (de_CH, not de), and is used for its
+	// registry tag, plural rules and overlay matching
+	for _, lang := range []string{"de", "de_CH", "en", "es", "fr", "root", "sv", "ta"} {
+		pack, err := RulesFromXMLFile("test_data/" + lang + ".xml")
+		if lang == "de_CH" {
+			// a reduced fixture, only valid with its parent locales
+			pack, err = RulesFromXMLFileInherited("test_data/de_CH.xml")
+		}
+		if err != nil {
+			t.Fatalf("Pain! %v", err)
+		}
+		if res := string(pack.Language); res != lang {
+			t.Errorf("wanted %s, got %s", lang, res)
+		}
+		reg := rbnf.NewRegistry()
+		reg.Add(pack)
+		if exp, res := strings.Replace(lang, "_", "-", -1), reg.Tags()[0].String(); lang != "root" && res != exp {
+			t.Errorf("wanted %s, got %s", exp, res)
+		}
+	}
+
+	overlay, err := OverlayFromXMLFile("test_data/overlay_sv.xml")
+	if err != nil {
+		t.Fatalf("Pain! %v", err)
+	}
+	if exp, res := rbnf.Language("sv"), overlay.Language; res != exp {
+		t.Errorf("wanted %s, got %s", exp, res)
+	}
+}
+
+func TestParentLocales(t *testing.T) {
+	tests := []struct {
+		locale string
+		expect string
+	}{
+		{"de_CH", "de root"},
+		{"en_IN", "en_001 en root"},
+		{"pt_PT", "pt root"},
+		{"zh_Hant", "root"},
+		{"sv", "root"},
+		{"root", ""},
+	}
+	for _, test := range tests {
+		if res := strings.Join(parentLocales(test.locale), " "); res != test.expect {
+			t.Errorf("%s: wanted %s, got %s", test.locale, test.expect, res)
+		}
+	}
+}
+
+func TestOverlayFromXMLFileSV(t *testing.T) {
+
+	pack, err := RulesFromXMLFile("test_data/sv.xml")
+	if err != nil {
+		t.Errorf("Pain! %v", err)
+		return
+	}
+	overlay, err := OverlayFromXMLFile("test_data/overlay_sv.xml")
+	if err != nil {
+		t.Errorf("Pain! %v", err)
+		return
+	}
+	patched, err := pack.ApplyOverlay(overlay)
+	if err != nil {
+		t.Errorf("Pain! %v", err)
+		return
+	}
+	// the same overlay in the CLDR 44+ layout
+	overlay44, err := OverlayFromXMLFile("test_data/overlay_sv_cldr44.xml")
+	if err != nil {
+		t.Errorf("Pain! %v", err)
+		return
+	}
+	patched44, err := pack.ApplyOverlay(overlay44)
+	if err != nil {
+		t.Errorf("Pain! %v", err)
+		return
+	}
+	if changes := rbnf.Diff(patched, patched44); len(changes) > 0 {
+		t.Errorf("Sob! %d changes between overlay layouts, first: %v", len(changes), changes[0])
+	}
+
+	tests := []struct {
+		pack    rbnf.RulePackage
+		ruleSet string
+		input   string
+		expect  string
+	}{
+		{pack, "spellout-numbering", "123", "ett­hundra­tjugo­tre"},
+		{patched, "spellout-numbering", "123", "hundra­tjugo­tre"},
+		{patched, "spellout-numbering", "223", "två­hundra­tjugo­tre"},
+		{patched, "spellout-numbering", "23", "tjugo­tre"},
+		{pack, "spellout-numbering-year", "1984", "nitton­hundra­åttio­fyra"},
+		{patched, "spellout-numbering-year", "1984", "et­tusen nio­hundra­åttio­fyra"},
+		{patched, "spellout-house-number", "12", "tolv"},
+	}
+	for _, test := range tests {
+		res, err := test.pack.Spellout(test.input, "SpelloutRules", test.ruleSet, false)
+		if err != nil {
+			t.Errorf("Sob! %s %s : %v", test.ruleSet, test.input, err)
+		} else if res != test.expect {
+			t.Errorf("wanted %s, got %s", test.expect, res)
+		}
+	}
+
+	// the overlay language must match
+	en, err := RulesFromXMLFile("test_data/en.xml")
+	if err != nil {
+		t.Errorf("Pain! %v", err)
+		return
+	}
+	if _, err := en.ApplyOverlay(overlay); err == nil {
+		t.Errorf("expected error for overlay language mismatch")
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	inputs := []string{"0", "1", "-7", "2.5", "13", "21", "99", "101", "1000", "1001", "1984", "2021", "12345", "1000000", "2000001", "123456789"}
+	for _, lang := range []string{"sv", "en", "de", "fr", "es", "ta"} {
+		pack, err := RulesFromXMLFile("test_data/" + lang + ".xml")
+		if err != nil {
+			t.Errorf("Pain! %v", err)
+			continue
+		}
+		data, err := json.Marshal(pack)
+		if err != nil {
+			t.Errorf("Pain! %v", err)
+			continue
+		}
+		var res rbnf.RulePackage
+		if err := json.Unmarshal(data, &res); err != nil {
+			t.Errorf("Pain! %s : %v", lang, err)
+			continue
+		}
+		for _, g := range pack.RuleSetGroups {
+			for _, rs := range g.RuleSets {
+				if rs.Private {
+					continue
+				}
+				for _, input := range inputs {
+					exp, expErr := pack.Spellout(input, g.Name, rs.Name, false)
+					got, gotErr := res.Spellout(input, g.Name, rs.Name, false)
+					if (expErr == nil) != (gotErr == nil) {
+						t.Errorf("Sob! %s %s %s : wanted error %v, got %v", lang, rs.Name, input, expErr, gotErr)
+					} else if exp != got {
+						t.Errorf("%s %s %s : wanted %s, got %s", lang, rs.Name, input, exp, got)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestVocabulary(t *testing.T) {
+	for _, lang := range []string{"en", "sv", "fr", "es", "ta"} {
+		pack, err := RulesFromXMLFile("test_data/" + lang + ".xml")
+		if err != nil {
+			t.Errorf("Pain! %v", err)
+			continue
+		}
+		for _, g := range pack.RuleSetGroups {
+			v := g.Vocabulary()
+			if v.Truncated {
+				t.Errorf("Sob! %s %s : vocabulary truncated", lang, g.Name)
+			}
+			words := make(map[string]bool)
+			for _, w := range v.Words {
+				words[w] = true
+			}
+			for _, rs := range g.RuleSets {
+				if rs.Private {
+					continue
+				}
+				for i := -3; i < 1200; i++ {
+					for _, input := range []string{fmt.Sprint(i), fmt.Sprint(i * 1037), fmt.Sprint(i * 1000003), fmt.Sprintf("%d.%d", i, i%13)} {
+						res, err := g.Spellout(input, rs.Name, false)
+						if err != nil {
+							continue
+						}
+						for _, w := range strings.Fields(res) {
+							if !words[w] && !strings.ContainsAny(w, "0123456789") {
+								t.Errorf("%s %s %s : %s not in vocabulary", lang, rs.Name, input, w)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestFST(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, lang := range []string{"en", "sv", "de", "fr", "es", "ta"} {
+		pack, err := RulesFromXMLFile("test_data/" + lang + ".xml")
+		if err != nil {
+			t.Errorf("Pain! %v", err)
+			continue
+		}
+		for _, g := range pack.RuleSetGroups {
+			for _, rs := range g.RuleSets {
+				if rs.Private {
+					continue
+				}
+				fst, err := g.FST(rs.Name, 0, 999999)
+				if err != nil {
+					// rules with numeric formatters are enumerated, for smaller ranges
+					fst, err = g.FST(rs.Name, 0, 9999)
+				}
+				if err != nil {
+					t.Errorf("Sob! %s %s : %v", lang, rs.Name, err)
+					continue
+				}
+				if err := fst.Check(500, rnd); err != nil {
+					t.Errorf("%s %s : %v", lang, rs.Name, err)
+				}
+			}
+		}
+	}
+}
+
+func TestRuleSetGraph(t *testing.T) {
+	for _, lang := range []string{"en", "sv", "de", "fr"} {
+		pack, err := RulesFromXMLFile("test_data/" + lang + ".xml")
+		if err != nil {
+			t.Errorf("Pain! %v", err)
+			continue
+		}
+		for _, g := range pack.RuleSetGroups {
+			gr := g.Graph()
+			if len(gr.Nodes) != len(g.RuleSets) {
+				t.Errorf("Sob! %s %s : wanted %d nodes, got %d", lang, g.Name, len(g.RuleSets), len(gr.Nodes))
+			}
+			for _, e := range gr.Edges {
+				if _, ok := g.RuleSets[e.To]; !ok {
+					t.Errorf("%s %s : edge to missing rule set %s", lang, g.Name, e.To)
+				}
+			}
+			for _, name := range gr.Unreachable() {
+				if !g.RuleSets[name].Private {
+					t.Errorf("%s %s : public rule set %s unreachable", lang, g.Name, name)
+				}
+			}
+		}
+	}
+
+	pack, err := RulesFromXMLFile("test_data/sv.xml")
+	if err != nil {
+		t.Fatalf("Pain! %v", err)
+	}
+	for _, g := range pack.RuleSetGroups {
+		if g.Name != "SpelloutRules" {
+			continue
+		}
+		reachable := " " + strings.Join(g.Graph().ReachableFrom("spellout-numbering-year"), " ") + " "
+		if !strings.Contains(reachable, " spellout-numbering ") {
+			t.Errorf("wanted spellout-numbering reachable from spellout-numbering-year, got %s", reachable)
+		}
+	}
+}
+
+func TestTrainingData(t *testing.T) {
+	for _, lang := range []string{"en", "sv", "de", "fr"} {
+		pack, err := RulesFromXMLFile("test_data/" + lang + ".xml")
+		if err != nil {
+			t.Errorf("Pain! %v", err)
+			continue
+		}
+		examples, err := pack.TrainingData(20, rbnf.TrainingSeed(1))
+		if err != nil {
+			t.Errorf("Sob! %s : %v", lang, err)
+			continue
+		}
+		if len(examples) == 0 {
+			t.Errorf("Sob! %s : no training examples", lang)
+		}
+		for _, e := range examples {
+			if e.Language != lang {
+				t.Errorf("wanted %s, got %s", lang, e.Language)
+			}
+			exp, err := pack.Spellout(e.Input, e.Group, e.RuleSet, false)
+			if err != nil {
+				t.Errorf("Sob! %s %s %s : %v", lang, e.RuleSet, e.Input, err)
+			} else if e.Output != exp {
+				t.Errorf("%s %s %s : wanted %s, got %s", lang, e.RuleSet, e.Input, exp, e.Output)
+			}
+		}
+	}
+}
+
+func TestRbnfRulesLayout(t *testing.T) {
+	old, err := RulesFromXMLFile("test_data/sv.xml")
+	if err != nil {
+		t.Fatalf("Pain! %v", err)
+	}
+	// sv_cldr44.xml uses arrows for substitutions, sv_cldr44_ascii.xml ASCII characters (<< >>), as in CLDR 44
+	for _, fn := range []string{"test_data/sv_cldr44.xml", "test_data/sv_cldr44_ascii.xml"} {
+		pack, err := RulesFromXMLFile(fn)
+		if err != nil {
+			t.Fatalf("Pain! %v", err)
+		}
+		if changes := rbnf.Diff(old, pack); len(changes) > 0 {
+			t.Errorf("Sob! %s : %d changes between layouts, first: %v", fn, len(changes), changes[0])
+		}
+		for _, input := range []string{"0", "-3", "2.5", "21", "1100", "1984", "2021", "123456", "1000001"} {
+			for rs, g := range map[string]string{"spellout-numbering": "SpelloutRules", "spellout-numbering-year": "SpelloutRules", "spellout-ordinal-neuter": "SpelloutRules", "digits-ordinal": "OrdinalRules"} {
+				exp, err := old.Spellout(input, g, rs, false)
+				if err != nil {
+					// no matching rule in either layout
+					continue
+				}
+				res, err := pack.Spellout(input, g, rs, false)
+				if err != nil {
+					t.Errorf("Pain! %v", err)
+				} else if res != exp {
+					t.Errorf("Sob! %s %s %s : wanted %s, got %s", fn, rs, input, exp, res)
+				}
+			}
+		}
+	}
+}
+
+func TestConvertRuleSetASCII(t *testing.T) {
+	// in rbnfrule elements, substitutions use arrows, and ASCII < and > are plain text
+	rs := &Ruleset{Attrtype: "test", Rbnfrule: []*Rbnfrule{
+		{Attrvalue: "0", String: "'<' a→→;"},
+		{Attrvalue: "10", String: "x >y→→;"},
+	}}
+	res, err := convertRuleSet(rs, "sv")
+	if err != nil {
+		t.Fatalf("Pain! %v", err)
+	}
+	var got []string
+	for _, r := range res.Rules {
+		got = append(got, r.String())
+	}
+	exp := []string{"0 (10) => ''<' a>>'", "10 (10) => 'x >y>>'"}
+	if strings.Join(got, "\n") != strings.Join(exp, "\n") {
+		t.Errorf("Sob! wanted\n%s\ngot\n%s", strings.Join(exp, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestICUBundle(t *testing.T) {
+	old, err := RulesFromXMLFile("test_data/sv.xml")
+	if err != nil {
+		t.Fatalf("Pain! %v", err)
+	}
+	pack, err := icureader.RulesFromBundleFile("../icureader/test_data/sv.txt")
+	if err != nil {
+		t.Fatalf("Pain! %v", err)
+	}
+	if changes := rbnf.Diff(old, pack); len(changes) > 0 {
+		t.Errorf("Sob! %d changes between xml and ICU4C bundle, first: %v", len(changes), changes[0])
+	}
+}
+
+func TestRulesFromXMLReader(t *testing.T) {
+	old, err := RulesFromXMLFile("test_data/sv.xml")
+	if err != nil {
+		t.Fatalf("Pain! %v", err)
+	}
+
+	f, err := os.Open("test_data/sv.xml")
+	if err != nil {
+		t.Fatalf("Pain! %v", err)
+	}
+	defer f.Close()
+	pack, err := RulesFromXMLReader(f)
+	if err != nil {
+		t.Fatalf("Pain! %v", err)
+	}
+	if changes := rbnf.Diff(old, pack); len(changes) > 0 {
+		t.Errorf("Sob! %d changes between file and reader, first: %v", len(changes), changes[0])
+	}
+
+	pack, err = RulesFromFS(os.DirFS("test_data"), "sv.xml")
+	if err != nil {
+		t.Fatalf("Pain! %v", err)
+	}
+	if changes := rbnf.Diff(old, pack); len(changes) > 0 {
+		t.Errorf("Sob! %d changes between file and fs, first: %v", len(changes), changes[0])
+	}
+
+	for _, input := range []string{"", "<ldml></ldml>", "<ldml><identity><language type=\"sv\"/></identity></ldml>"} {
+		if _, err := RulesFromXMLReader(strings.NewReader(input)); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+	if _, err := RulesFromFS(os.DirFS("test_data"), "xx.xml"); err == nil {
+		t.Errorf("expected error for missing file")
+	}
+}
+
+// testDirFS returns a CLDR-like common/rbnf directory, with a broken file and a file that is not a rule file
+func testDirFS(t *testing.T) fstest.MapFS {
+	res := fstest.MapFS{
+		"common/rbnf/broken.xml": &fstest.MapFile{Data: []byte("<ldml><identity>")},
+		"common/rbnf/README.txt": &fstest.MapFile{Data: []byte("not a rule file")},
+	}
+	for _, lang := range []string{"sv", "en", "de", "de_CH", "root"} {
+		data, err := ioutil.ReadFile("test_data/" + lang + ".xml")
+		if err != nil {
+			t.Fatalf("Pain! %v", err)
+		}
+		res["common/rbnf/"+lang+".xml"] = &fstest.MapFile{Data: data}
+	}
+	return res
+}
+
+func TestLoadDir(t *testing.T) {
+	fsys := testDirFS(t)
+
+	dir, err := LoadDir(fsys, "common/rbnf")
+	if err != nil {
+		t.Fatalf("Pain! %v", err)
+	}
+	if exp, res := "broken de de_CH en root sv", fmt.Sprint(dir.Languages()); res != "["+exp+"]" {
+		t.Errorf("wanted [%s], got %s", exp, res)
+	}
+	// de_CH can't be read without its parents
+	errs := dir.Errors()
+	if len(errs) != 2 {
+		t.Fatalf("Sob! expected 2 errors, got %v", errs)
+	}
+	var fileErr *FileError
+	if !errors.As(errs[0], &fileErr) || fileErr.Path != "common/rbnf/broken.xml" {
+		t.Errorf("Sob! expected error for broken.xml, got %v", errs[0])
+	}
+	if exp, res := 4, len(dir.Packages()); res != exp {
+		t.Errorf("wanted %d packages, got %d", exp, res)
+	}
+	pack, err := dir.Package("sv")
+	if err != nil {
+		t.Fatalf("Pain! %v", err)
+	}
+	if res, err := pack.Spellout("21", "SpelloutRules", "spellout-numbering", false); err != nil {
+		t.Errorf("Sob! %v", err)
+	} else if w := "tjugo­ett"; res != w {
+		t.Errorf("wanted %s, got %s", w, res)
+	}
+	if _, err := dir.Package("ja"); err == nil {
+		t.Errorf("expected error for missing language")
+	}
+
+	// lazy loading, with inheritance
+	dir, err = LoadDir(fsys, "common/rbnf", DirLazy(), DirInherit())
+	if err != nil {
+		t.Fatalf("Pain! %v", err)
+	}
+	if errs := dir.Errors(); len(errs) != 0 {
+		t.Errorf("Sob! expected no errors before loading, got %v", errs)
+	}
+	reg := rbnf.NewRegistry()
+	dir.Register(reg)
+	if exp, res := 5, len(reg.Tags()); res != exp {
+		t.Errorf("wanted %d tags, got %d", exp, res)
+	}
+	if res, err := reg.Spellout(language.MustParse("de-CH"), "31", "SpelloutRules", "spellout-numbering", false); err != nil {
+		t.Errorf("Sob! %v", err)
+	} else if w := "ein­und­dreissig"; res != w {
+		t.Errorf("wanted %s, got %s", w, res)
+	}
+	if errs := dir.Errors(); len(errs) != 0 {
+		t.Errorf("Sob! expected no errors, got %v", errs)
+	}
+	if _, err := dir.Package("broken"); err == nil {
+		t.Errorf("expected error for broken.xml")
+	}
+	if exp, res := 1, len(dir.Errors()); res != exp {
+		t.Errorf("wanted %d errors, got %d", exp, res)
+	}
+
+	if _, err := LoadDir(fsys, "common/collation"); err == nil {
+		t.Errorf("expected error for missing directory")
+	}
+}
+
+// countingFS counts the files opened in a file system
+type countingFS struct {
+	fs.FS
+	mu     sync.Mutex
+	opened map[string]int
+}
+
+func (c *countingFS) Open(name string) (fs.File, error) {
+	c.mu.Lock()
+	c.opened[name]++
+	c.mu.Unlock()
+	return c.FS.Open(name)
+}
+
+func TestLoadDirInherit(t *testing.T) {
+	fsys := testDirFS(t)
+	delete(fsys, "common/rbnf/broken.xml")
+	counter := &countingFS{FS: fsys, opened: make(map[string]int)}
+
+	dir, err := LoadDir(counter, "common/rbnf", DirInherit())
+	if err != nil {
+		t.Fatalf("Pain! %v", err)
+	}
+	if errs := dir.Errors(); len(errs) != 0 {
+		t.Fatalf("Sob! expected no errors, got %v", errs)
+	}
+	// parent files (de.xml, root.xml) are read once, not once per child locale
+	for name, n := range counter.opened {
+		if n != 1 {
+			t.Errorf("Sob! %s was opened %d times", name, n)
+		}
+	}
+	pkgs := dir.Packages()
+	if exp, res := 5, len(pkgs); res != exp {
+		t.Errorf("wanted %d packages, got %d", exp, res)
+	}
+	for lang, pkg := range pkgs {
+		if pkg.Language != lang {
+			t.Errorf("Sob! wanted language %s, got %s", lang, pkg.Language)
+		}
+	}
+	exp, err := RulesFromFSInherited(fsys, "common/rbnf/de_CH.xml")
+	if err != nil {
+		t.Fatalf("Pain! %v", err)
+	}
+	if changes := rbnf.Diff(exp, *pkgs["de_CH"]); len(changes) > 0 {
+		t.Errorf("Sob! %d changes from RulesFromFSInherited, first: %v", len(changes), changes[0])
+	}
+
+	// the file name and the locale of the file must agree
+	fsys = fstest.MapFS{"rbnf/sv_FI.xml": fsys["common/rbnf/sv.xml"]}
+	for _, opts := range [][]DirOption{nil, {DirInherit()}} {
+		dir, err = LoadDir(fsys, "rbnf", opts...)
+		if err != nil {
+			t.Fatalf("Pain! %v", err)
+		}
+		if _, err := dir.Package("sv_FI"); err == nil || !strings.Contains(err.Error(), "locale sv") {
+			t.Errorf("Sob! expected error for mismatching locale, got %v", err)
+		}
+	}
+}